@@ -0,0 +1,178 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/urfave/cli/v3"
+	"github.com/zyrouge/pho/core"
+	"github.com/zyrouge/pho/utils"
+)
+
+var AppConfigDesktopCommand = cli.Command{
+	Name:  "desktop",
+	Usage: "Override entries of an application's .desktop file",
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:  "name",
+			Usage: "Display name",
+		},
+		&cli.StringSliceFlag{
+			Name:  "categories",
+			Usage: "Menu categories",
+		},
+		&cli.StringSliceFlag{
+			Name:  "keywords",
+			Usage: "Search keywords",
+		},
+		&cli.BoolFlag{
+			Name:  "no-display",
+			Usage: "Hide from menus",
+		},
+		&cli.StringSliceFlag{
+			Name:  "exec-arg",
+			Usage: "Additional argument passed to the AppImage",
+		},
+		&cli.StringMapFlag{
+			Name:  "env",
+			Usage: "Environment variable as KEY=VALUE",
+		},
+		&cli.StringSliceFlag{
+			Name:  "unset-env",
+			Usage: "Remove an environment variable",
+		},
+		&cli.BoolFlag{
+			Name:  "reset",
+			Usage: "Remove all overrides",
+		},
+	},
+	Action: func(_ context.Context, cmd *cli.Command) error {
+		unlock, err := core.LockState()
+		if err != nil {
+			return err
+		}
+		defer unlock()
+
+		utils.LogDebug("reading config")
+		config, err := core.GetConfig()
+		if err != nil {
+			return err
+		}
+
+		args := cmd.Args()
+		if args.Len() == 0 {
+			return errors.New("no application id specified")
+		}
+		if args.Len() > 1 {
+			return errors.New("unexpected excessive arguments")
+		}
+
+		appId := args.Get(0)
+		reset := cmd.Bool("reset")
+		utils.LogDebug(fmt.Sprintf("argument id: %s", appId))
+		utils.LogDebug(fmt.Sprintf("argument reset: %v", reset))
+
+		if _, ok := config.Installed[appId]; !ok {
+			return fmt.Errorf(
+				"application with id %s is not installed",
+				color.CyanString(appId),
+			)
+		}
+
+		appConfigPath := core.GetAppConfigPath(config, appId)
+		utils.LogDebug(fmt.Sprintf("reading app config from %s", appConfigPath))
+		app, err := core.ReadAppConfig(appConfigPath)
+		if err != nil {
+			return err
+		}
+
+		if app.Headless {
+			return fmt.Errorf(
+				"application with id %s is headless and has no .desktop file",
+				color.CyanString(appId),
+			)
+		}
+
+		overrides := &app.DesktopOverrides
+		modified := reset
+		if reset {
+			*overrides = core.AppDesktopOverrides{}
+		}
+		if cmd.IsSet("name") {
+			overrides.Name = cmd.String("name")
+			modified = true
+		}
+		if cmd.IsSet("categories") {
+			overrides.Categories = cmd.StringSlice("categories")
+			modified = true
+		}
+		if cmd.IsSet("keywords") {
+			overrides.Keywords = cmd.StringSlice("keywords")
+			modified = true
+		}
+		if noDisplaySet, noDisplay := utils.CommandBoolSetAndValue(cmd, "no-display"); noDisplaySet {
+			overrides.NoDisplay = &noDisplay
+			modified = true
+		}
+		if cmd.IsSet("exec-arg") {
+			overrides.ExecArgs = cmd.StringSlice("exec-arg")
+			modified = true
+		}
+		if cmd.IsSet("env") {
+			if overrides.Env == nil {
+				overrides.Env = map[string]string{}
+			}
+			for k, v := range cmd.StringMap("env") {
+				overrides.Env[k] = v
+			}
+			modified = true
+		}
+		if cmd.IsSet("unset-env") {
+			for _, x := range cmd.StringSlice("unset-env") {
+				delete(overrides.Env, x)
+			}
+			modified = true
+		}
+
+		if modified {
+			utils.LogDebug(fmt.Sprintf("installing .desktop file at %s", app.Paths.Desktop))
+			if err = core.ReinstallDesktopFile(app); err != nil {
+				return err
+			}
+			utils.LogDebug(fmt.Sprintf("saving app config to %s", appConfigPath))
+			if err = core.SaveAppConfig(appConfigPath, app); err != nil {
+				return err
+			}
+		}
+
+		utils.LogLn()
+		summary := utils.NewLogTable()
+		summary.Add(utils.LogRightArrowPrefix, "Name", color.CyanString(overrides.Name))
+		summary.Add(utils.LogRightArrowPrefix, "Categories", color.CyanString(strings.Join(overrides.Categories, ", ")))
+		summary.Add(utils.LogRightArrowPrefix, "Keywords", color.CyanString(strings.Join(overrides.Keywords, ", ")))
+		noDisplay := ""
+		if overrides.NoDisplay != nil {
+			noDisplay = utils.BoolToYesNo(*overrides.NoDisplay)
+		}
+		summary.Add(utils.LogRightArrowPrefix, "No Display", color.CyanString(noDisplay))
+		summary.Add(utils.LogRightArrowPrefix, "Exec Arguments", color.CyanString(strings.Join(overrides.ExecArgs, " ")))
+		summary.Add(utils.LogRightArrowPrefix, "Environment", color.CyanString(strings.Join(utils.FormatEnvMap(overrides.Env), " ")))
+		summary.Print()
+
+		if modified {
+			utils.LogLn()
+			utils.LogInfo(
+				fmt.Sprintf(
+					"%s Updated .desktop file of %s successfully!",
+					utils.LogTickPrefix,
+					color.CyanString(appId),
+				),
+			)
+		}
+
+		return nil
+	},
+}
@@ -0,0 +1,152 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/urfave/cli/v3"
+	"github.com/zyrouge/pho/core"
+	"github.com/zyrouge/pho/utils"
+)
+
+var AppConfigLaunchCommand = cli.Command{
+	Name:  "launch",
+	Usage: "Update an application's launch profile",
+	Flags: []cli.Flag{
+		&cli.StringMapFlag{
+			Name:  "env",
+			Usage: "Environment variable as KEY=VALUE",
+		},
+		&cli.StringSliceFlag{
+			Name:  "unset-env",
+			Usage: "Remove an environment variable",
+		},
+		&cli.StringSliceFlag{
+			Name:  "arg",
+			Usage: "Default argument passed to the AppImage",
+		},
+		&cli.StringFlag{
+			Name:  "dir",
+			Usage: "Working directory",
+		},
+		&cli.BoolFlag{
+			Name:  "reset",
+			Usage: "Remove all launch options",
+		},
+	},
+	Action: func(_ context.Context, cmd *cli.Command) error {
+		unlock, err := core.LockState()
+		if err != nil {
+			return err
+		}
+		defer unlock()
+
+		utils.LogDebug("reading config")
+		config, err := core.GetConfig()
+		if err != nil {
+			return err
+		}
+
+		args := cmd.Args()
+		if args.Len() == 0 {
+			return errors.New("no application id specified")
+		}
+		if args.Len() > 1 {
+			return errors.New("unexpected excessive arguments")
+		}
+
+		appId := args.Get(0)
+		reset := cmd.Bool("reset")
+		utils.LogDebug(fmt.Sprintf("argument id: %s", appId))
+		utils.LogDebug(fmt.Sprintf("argument reset: %v", reset))
+
+		if _, ok := config.Installed[appId]; !ok {
+			return fmt.Errorf(
+				"application with id %s is not installed",
+				color.CyanString(appId),
+			)
+		}
+
+		appConfigPath := core.GetAppConfigPath(config, appId)
+		utils.LogDebug(fmt.Sprintf("reading app config from %s", appConfigPath))
+		app, err := core.ReadAppConfig(appConfigPath)
+		if err != nil {
+			return err
+		}
+
+		profile := &app.Launch
+		modified := reset
+		if reset {
+			*profile = core.AppLaunchProfile{}
+		}
+		if cmd.IsSet("env") {
+			if profile.Env == nil {
+				profile.Env = map[string]string{}
+			}
+			for k, v := range cmd.StringMap("env") {
+				profile.Env[k] = v
+			}
+			modified = true
+		}
+		if cmd.IsSet("unset-env") {
+			for _, x := range cmd.StringSlice("unset-env") {
+				delete(profile.Env, x)
+			}
+			modified = true
+		}
+		if cmd.IsSet("arg") {
+			profile.Args = cmd.StringSlice("arg")
+			modified = true
+		}
+		if cmd.IsSet("dir") {
+			dir := cmd.String("dir")
+			if dir != "" {
+				dir, err = utils.ResolvePath(dir)
+				if err != nil {
+					return err
+				}
+			}
+			profile.WorkingDir = dir
+			modified = true
+		}
+
+		if modified {
+			utils.LogDebug(fmt.Sprintf("installing .desktop file at %s", app.Paths.Desktop))
+			if err = core.ReinstallDesktopFile(app); err != nil {
+				return err
+			}
+			utils.LogDebug(fmt.Sprintf("saving app config to %s", appConfigPath))
+			if err = core.SaveAppConfig(appConfigPath, app); err != nil {
+				return err
+			}
+		}
+
+		utils.LogLn()
+		summary := utils.NewLogTable()
+		summary.Add(utils.LogRightArrowPrefix, "Environment", color.CyanString(strings.Join(utils.FormatEnvMap(profile.Env), " ")))
+		summary.Add(utils.LogRightArrowPrefix, "Arguments", color.CyanString(strings.Join(profile.Args, " ")))
+		summary.Add(utils.LogRightArrowPrefix, "Working Directory", color.CyanString(profile.WorkingDir))
+		summary.Print()
+
+		if modified && (len(profile.Env) > 0 || len(profile.Args) > 0 || profile.WorkingDir != "") {
+			utils.LogLn()
+			warnSymlinkLaunch(app, "Launch profiles")
+		}
+
+		if modified {
+			utils.LogLn()
+			utils.LogInfo(
+				fmt.Sprintf(
+					"%s Updated launch profile of %s successfully!",
+					utils.LogTickPrefix,
+					color.CyanString(appId),
+				),
+			)
+		}
+
+		return nil
+	},
+}
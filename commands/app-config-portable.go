@@ -0,0 +1,130 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/urfave/cli/v3"
+	"github.com/zyrouge/pho/core"
+	"github.com/zyrouge/pho/utils"
+)
+
+var AppConfigPortableCommand = cli.Command{
+	Name:  "portable",
+	Usage: "Toggle portable home and config directories of an application",
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:  "migrate",
+			Usage: "Copy existing data from ~/.config/<name> into the portable config directory",
+		},
+	},
+	Action: func(_ context.Context, cmd *cli.Command) error {
+		unlock, err := core.LockState()
+		if err != nil {
+			return err
+		}
+		defer unlock()
+
+		utils.LogDebug("reading config")
+		config, err := core.GetConfig()
+		if err != nil {
+			return err
+		}
+
+		args := cmd.Args()
+		if args.Len() == 0 {
+			return errors.New("no application id specified")
+		}
+		if args.Len() == 1 {
+			return errors.New("no state specified, use on or off")
+		}
+		if args.Len() > 2 {
+			return errors.New("unexpected excessive arguments")
+		}
+
+		appId := args.Get(0)
+		state := strings.ToLower(args.Get(1))
+		migrate := cmd.String("migrate")
+		utils.LogDebug(fmt.Sprintf("argument id: %s", appId))
+		utils.LogDebug(fmt.Sprintf("argument state: %s", state))
+		utils.LogDebug(fmt.Sprintf("argument migrate: %s", migrate))
+
+		if state != "on" && state != "off" {
+			return fmt.Errorf("invalid state %s, use on or off", color.CyanString(state))
+		}
+		enable := state == "on"
+		if !enable && migrate != "" {
+			return errors.New("migrate can only be used when turning on portable mode")
+		}
+
+		if _, ok := config.Installed[appId]; !ok {
+			return fmt.Errorf(
+				"application with id %s is not installed",
+				color.CyanString(appId),
+			)
+		}
+
+		appConfigPath := core.GetAppConfigPath(config, appId)
+		utils.LogDebug(fmt.Sprintf("reading app config from %s", appConfigPath))
+		app, err := core.ReadAppConfig(appConfigPath)
+		if err != nil {
+			return err
+		}
+
+		if enable {
+			utils.LogDebug("enabling portable directories")
+			if err = core.EnablePortableDirs(app.Paths.AppImage); err != nil {
+				return err
+			}
+			if migrate != "" {
+				utils.LogDebug(fmt.Sprintf("migrating %s into portable config directory", migrate))
+				if err = core.MigratePortableConfig(app.Paths.AppImage, migrate); err != nil {
+					return err
+				}
+			}
+		} else {
+			utils.LogDebug("disabling portable directories")
+			if err = core.DisablePortableDirs(app.Paths.AppImage); err != nil {
+				return err
+			}
+		}
+		app.Portable = enable
+		utils.LogDebug(fmt.Sprintf("installing .desktop file at %s", app.Paths.Desktop))
+		if err = core.ReinstallDesktopFile(app); err != nil {
+			return err
+		}
+		utils.LogDebug(fmt.Sprintf("saving app config to %s", appConfigPath))
+		if err = core.SaveAppConfig(appConfigPath, app); err != nil {
+			return err
+		}
+
+		utils.LogLn()
+		if enable {
+			summary := utils.NewLogTable()
+			for _, x := range core.GetPortableDirs(app.Paths.AppImage) {
+				summary.Add(utils.LogRightArrowPrefix, color.CyanString(x))
+			}
+			summary.Print()
+			utils.LogLn()
+			// the AppRun of an extracted app gets the portable directories
+			// only through the environment
+			if app.Extracted {
+				warnSymlinkLaunch(app, "Portable directories")
+				utils.LogLn()
+			}
+		}
+		utils.LogInfo(
+			fmt.Sprintf(
+				"%s Turned %s portable mode of %s successfully!",
+				utils.LogTickPrefix,
+				state,
+				color.CyanString(appId),
+			),
+		)
+
+		return nil
+	},
+}
@@ -0,0 +1,187 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/urfave/cli/v3"
+	"github.com/zyrouge/pho/core"
+	"github.com/zyrouge/pho/utils"
+)
+
+var AppConfigSandboxCommand = cli.Command{
+	Name:  "sandbox",
+	Usage: "Update an application's sandbox policy",
+	Flags: []cli.Flag{
+		&cli.BoolFlag{
+			Name:  "enable",
+			Usage: "Always launch inside a sandbox",
+		},
+		&cli.StringFlag{
+			Name:  "backend",
+			Usage: "Sandbox backend (bwrap, firejail)",
+		},
+		&cli.StringSliceFlag{
+			Name:  "allow",
+			Usage: "Path that is accessible inside the sandbox",
+		},
+		&cli.StringSliceFlag{
+			Name:  "disallow",
+			Usage: "Remove an accessible path",
+		},
+		&cli.BoolFlag{
+			Name:  "network",
+			Usage: "Allow network access",
+		},
+		&cli.BoolFlag{
+			Name:  "devices",
+			Usage: "Allow access to devices",
+		},
+		&cli.BoolFlag{
+			Name:  "reset",
+			Usage: "Remove the sandbox policy",
+		},
+	},
+	Action: func(_ context.Context, cmd *cli.Command) error {
+		unlock, err := core.LockState()
+		if err != nil {
+			return err
+		}
+		defer unlock()
+
+		utils.LogDebug("reading config")
+		config, err := core.GetConfig()
+		if err != nil {
+			return err
+		}
+
+		args := cmd.Args()
+		if args.Len() == 0 {
+			return errors.New("no application id specified")
+		}
+		if args.Len() > 1 {
+			return errors.New("unexpected excessive arguments")
+		}
+
+		appId := args.Get(0)
+		reset := cmd.Bool("reset")
+		utils.LogDebug(fmt.Sprintf("argument id: %s", appId))
+		utils.LogDebug(fmt.Sprintf("argument reset: %v", reset))
+
+		if _, ok := config.Installed[appId]; !ok {
+			return fmt.Errorf(
+				"application with id %s is not installed",
+				color.CyanString(appId),
+			)
+		}
+
+		appConfigPath := core.GetAppConfigPath(config, appId)
+		utils.LogDebug(fmt.Sprintf("reading app config from %s", appConfigPath))
+		app, err := core.ReadAppConfig(appConfigPath)
+		if err != nil {
+			return err
+		}
+
+		policy := &app.Sandbox
+		modified := reset
+		if reset {
+			*policy = core.AppSandboxPolicy{}
+		}
+		if enableSet, enable := utils.CommandBoolSetAndValue(cmd, "enable"); enableSet {
+			policy.Enabled = enable
+			modified = true
+		}
+		if cmd.IsSet("backend") {
+			backend, err := core.ParseSandboxBackend(cmd.String("backend"))
+			if err != nil {
+				return err
+			}
+			policy.Backend = backend
+			modified = true
+		}
+		if cmd.IsSet("allow") {
+			allowed, err := core.ResolveSandboxPaths(cmd.StringSlice("allow"))
+			if err != nil {
+				return err
+			}
+			for _, x := range allowed {
+				if !utils.SliceContains(policy.Filesystem, x) {
+					policy.Filesystem = append(policy.Filesystem, x)
+				}
+			}
+			modified = true
+		}
+		if cmd.IsSet("disallow") {
+			disallowed, err := core.ResolveSandboxPaths(cmd.StringSlice("disallow"))
+			if err != nil {
+				return err
+			}
+			filesystem := []string{}
+			for _, x := range policy.Filesystem {
+				if !utils.SliceContains(disallowed, x) {
+					filesystem = append(filesystem, x)
+				}
+			}
+			policy.Filesystem = filesystem
+			modified = true
+		}
+		if networkSet, network := utils.CommandBoolSetAndValue(cmd, "network"); networkSet {
+			policy.Network = network
+			modified = true
+		}
+		if devicesSet, devices := utils.CommandBoolSetAndValue(cmd, "devices"); devicesSet {
+			policy.Devices = devices
+			modified = true
+		}
+
+		if modified {
+			if policy.Enabled {
+				if _, _, err = core.FindSandboxBackend(policy.Backend); err != nil {
+					return err
+				}
+			}
+			utils.LogDebug(fmt.Sprintf("installing .desktop file at %s", app.Paths.Desktop))
+			if err = core.ReinstallDesktopFile(app); err != nil {
+				return err
+			}
+			utils.LogDebug(fmt.Sprintf("saving app config to %s", appConfigPath))
+			if err = core.SaveAppConfig(appConfigPath, app); err != nil {
+				return err
+			}
+		}
+
+		backend := string(policy.Backend)
+		if backend == "" {
+			backend = "auto"
+		}
+		utils.LogLn()
+		summary := utils.NewLogTable()
+		summary.Add(utils.LogRightArrowPrefix, "Enabled", color.CyanString(utils.BoolToYesNo(policy.Enabled)))
+		summary.Add(utils.LogRightArrowPrefix, "Backend", color.CyanString(backend))
+		summary.Add(utils.LogRightArrowPrefix, "Filesystem", color.CyanString(strings.Join(policy.Filesystem, ", ")))
+		summary.Add(utils.LogRightArrowPrefix, "Network", color.CyanString(utils.BoolToYesNo(policy.Network)))
+		summary.Add(utils.LogRightArrowPrefix, "Devices", color.CyanString(utils.BoolToYesNo(policy.Devices)))
+		summary.Print()
+
+		if modified && policy.Enabled {
+			utils.LogLn()
+			warnSymlinkLaunch(app, "Sandbox policies")
+		}
+
+		if modified {
+			utils.LogLn()
+			utils.LogInfo(
+				fmt.Sprintf(
+					"%s Updated sandbox policy of %s successfully!",
+					utils.LogTickPrefix,
+					color.CyanString(appId),
+				),
+			)
+		}
+
+		return nil
+	},
+}
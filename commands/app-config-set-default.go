@@ -0,0 +1,119 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/urfave/cli/v3"
+	"github.com/zyrouge/pho/core"
+	"github.com/zyrouge/pho/utils"
+)
+
+var AppConfigSetDefaultCommand = cli.Command{
+	Name:  "set-default",
+	Usage: "Set an application as the default handler of mime types",
+	Action: func(_ context.Context, cmd *cli.Command) error {
+		unlock, err := core.LockState()
+		if err != nil {
+			return err
+		}
+		defer unlock()
+
+		utils.LogDebug("reading config")
+		config, err := core.GetConfig()
+		if err != nil {
+			return err
+		}
+
+		args := cmd.Args()
+		if args.Len() == 0 {
+			return errors.New("no application id specified")
+		}
+		if args.Len() == 1 {
+			return errors.New("no mime types specified")
+		}
+
+		appId := args.Get(0)
+		mimeTypes := args.Slice()[1:]
+		utils.LogDebug(fmt.Sprintf("argument id: %s", appId))
+		utils.LogDebug(fmt.Sprintf("argument mime-types: %s", strings.Join(mimeTypes, ", ")))
+
+		if _, ok := config.Installed[appId]; !ok {
+			return fmt.Errorf(
+				"application with id %s is not installed",
+				color.CyanString(appId),
+			)
+		}
+
+		appConfigPath := core.GetAppConfigPath(config, appId)
+		utils.LogDebug(fmt.Sprintf("reading app config from %s", appConfigPath))
+		app, err := core.ReadAppConfig(appConfigPath)
+		if err != nil {
+			return err
+		}
+
+		if app.Headless {
+			return fmt.Errorf(
+				"application with id %s is headless and has no .desktop file",
+				color.CyanString(appId),
+			)
+		}
+
+		utils.LogDebug(fmt.Sprintf("reading .desktop file at %s", app.Paths.Desktop))
+		desktopContent, err := os.ReadFile(app.Paths.Desktop)
+		if err != nil {
+			return err
+		}
+		entry, err := core.ParseDesktopEntry(string(desktopContent))
+		if err != nil {
+			return err
+		}
+		mainGroup, err := entry.MainGroup()
+		if err != nil {
+			return err
+		}
+		declaredMimeTypes, _ := mainGroup.GetList("MimeType")
+
+		for _, x := range mimeTypes {
+			if !strings.Contains(x, "/") {
+				return fmt.Errorf("invalid mime type %s", color.CyanString(x))
+			}
+			if !utils.SliceContains(declaredMimeTypes, x) {
+				utils.LogWarning(
+					fmt.Sprintf(
+						"%s does not declare support for %s",
+						color.CyanString(appId),
+						color.CyanString(x),
+					),
+				)
+			}
+			utils.LogDebug(fmt.Sprintf("setting default handler of %s", x))
+			if err = core.SetDefaultMimeHandler(app.Paths.Desktop, x); err != nil {
+				return err
+			}
+			if !utils.SliceContains(app.MimeDefaults, x) {
+				app.MimeDefaults = append(app.MimeDefaults, x)
+			}
+		}
+		utils.LogDebug(fmt.Sprintf("saving app config to %s", appConfigPath))
+		if err = core.SaveAppConfig(appConfigPath, app); err != nil {
+			return err
+		}
+
+		utils.LogLn()
+		utils.LogInfo(
+			fmt.Sprintf(
+				"%s Set %s as the default application for %s successfully!",
+				utils.LogTickPrefix,
+				color.CyanString(appId),
+				color.CyanString(strings.Join(mimeTypes, ", ")),
+			),
+		)
+
+		return nil
+	},
+}
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"os"
 	"path"
+	"strings"
 
 	"github.com/fatih/color"
 	"github.com/urfave/cli/v3"
@@ -17,6 +18,12 @@ var AppConfigSetIdCommand = cli.Command{
 	Name:  "set-id",
 	Usage: "Update an application's identifier",
 	Action: func(_ context.Context, cmd *cli.Command) error {
+		unlock, err := core.LockState()
+		if err != nil {
+			return err
+		}
+		defer unlock()
+
 		utils.LogDebug("reading config")
 		config, err := core.GetConfig()
 		if err != nil {
@@ -62,10 +69,25 @@ var AppConfigSetIdCommand = cli.Command{
 		toAppPaths := core.ConstructAppPaths(config, toAppId, &core.ConstructAppPathsOptions{
 			Symlink: fromAppPaths.Symlink != "",
 		})
+		toAppPaths.Icon = strings.TrimSuffix(toAppPaths.Icon, path.Ext(toAppPaths.Icon)) + path.Ext(fromAppPaths.Icon)
+		toAppPaths.Icons = fromAppPaths.Icons
+		desktopContent := ""
+		if fromAppPaths.Icon == "" {
+			toAppPaths.Icon = ""
+		}
+		if app.Headless {
+			toAppPaths.Desktop = ""
+			toAppPaths.DesktopTemplate = ""
+			toAppPaths.Icon = ""
+		} else {
+			utils.LogDebug("reading .desktop file template")
+			desktopContent, err = core.ReadDesktopTemplate(&fromAppPaths)
+			if err != nil {
+				return err
+			}
+		}
 		app.Id = toAppId
 		app.Paths = *toAppPaths
-		delete(config.Installed, fromAppId)
-		config.Installed[toAppId] = toAppPaths.Config
 		utils.LogDebug(fmt.Sprintf("moving from %s to %s", fromAppPaths.Dir, toAppPaths.Dir))
 		if err = os.Rename(fromAppPaths.Dir, toAppPaths.Dir); err != nil {
 			return err
@@ -74,38 +96,77 @@ var AppConfigSetIdCommand = cli.Command{
 		if err = os.Rename(fromAppImagePath, toAppPaths.AppImage); err != nil {
 			return err
 		}
-		fromIconPath := path.Join(toAppPaths.Dir, path.Base(fromAppPaths.Icon))
-		if err = os.Rename(fromIconPath, toAppPaths.Icon); err != nil {
+		utils.LogDebug("renaming portable directories")
+		if err = core.RenamePortableDirs(fromAppImagePath, toAppPaths.AppImage); err != nil {
 			return err
 		}
-		utils.LogDebug(fmt.Sprintf("saving app config to %s", toAppPaths.Config))
-		if err = core.SaveAppConfig(toAppPaths.Config, app); err != nil {
-			return err
+		if toAppPaths.Icon != "" {
+			fromIconPath := path.Join(toAppPaths.Dir, path.Base(fromAppPaths.Icon))
+			err = os.Rename(fromIconPath, toAppPaths.Icon)
+			if errors.Is(err, os.ErrNotExist) {
+				// older versions recorded an icon path for appimages without an icon
+				toAppPaths.Icon = ""
+			} else if err != nil {
+				return err
+			}
 		}
-		utils.LogDebug("saving config")
-		if err = core.SaveConfig(config); err != nil {
+		utils.LogDebug("renaming icons")
+		if err = core.RenameThemeIcons(toAppPaths, core.ConstructAppIconName(toAppId)); err != nil {
 			return err
 		}
-		utils.LogDebug(fmt.Sprintf("reading .desktop file at %s", fromAppPaths.Desktop))
-		desktopContent, err := os.ReadFile(fromAppPaths.Desktop)
-		if err != nil {
+		if err = core.RefreshIconCache(config.IconsDir); err != nil {
+			utils.LogDebug(fmt.Sprintf("unable to refresh icon cache: %v", err))
+		}
+		toAppPaths.MimePackages = fromAppPaths.MimePackages
+		utils.LogDebug("renaming mime packages")
+		if err = core.RenameMimePackages(toAppPaths, fromAppId, toAppId); err != nil {
 			return err
 		}
-		utils.LogDebug(fmt.Sprintf("uninstalling .desktop file at %s", fromAppPaths.Desktop))
-		if err = core.UninstallDesktopFile(fromAppPaths.Desktop); err != nil {
+		if err = core.UpdateMimeDatabase(config.MimeDir); err != nil {
+			utils.LogDebug(fmt.Sprintf("unable to update mime database: %v", err))
+		}
+		app.Paths = *toAppPaths
+		utils.LogDebug(fmt.Sprintf("saving app config to %s", toAppPaths.Config))
+		if err = core.SaveAppConfig(toAppPaths.Config, app); err != nil {
 			return err
 		}
-		utils.LogDebug(fmt.Sprintf("installing .desktop file at %s", fromAppPaths.Desktop))
-		if err = core.InstallDesktopFile(toAppPaths, string(desktopContent)); err != nil {
+		utils.LogDebug("saving config")
+		err = core.UpdateConfig(func(config *core.Config) error {
+			delete(config.Installed, fromAppId)
+			config.Installed[toAppId] = toAppPaths.Config
+			return nil
+		})
+		if err != nil {
 			return err
 		}
+		if !app.Headless {
+			utils.LogDebug(fmt.Sprintf("uninstalling .desktop file at %s", fromAppPaths.Desktop))
+			if err = core.UninstallDesktopFile(fromAppPaths.Desktop); err != nil {
+				return err
+			}
+			utils.LogDebug(fmt.Sprintf("installing .desktop file at %s", toAppPaths.Desktop))
+			if err = core.InstallDesktopFile(app, desktopContent); err != nil {
+				return err
+			}
+			if len(app.MimeDefaults) > 0 {
+				utils.LogDebug("moving mime associations")
+				if err = core.RemoveMimeAssociations(fromAppPaths.Desktop); err != nil {
+					return err
+				}
+				for _, x := range app.MimeDefaults {
+					if err = core.SetDefaultMimeHandler(toAppPaths.Desktop, x); err != nil {
+						return err
+					}
+				}
+			}
+		}
 		if toAppPaths.Symlink != "" {
 			utils.LogDebug(fmt.Sprintf("removing symlink at %s", fromAppPaths.Symlink))
 			if err = os.Remove(fromAppPaths.Symlink); err != nil {
 				return err
 			}
 			utils.LogDebug(fmt.Sprintf("creating symlink at %s", toAppPaths.Symlink))
-			if err = os.Symlink(toAppPaths.AppImage, toAppPaths.Symlink); err != nil {
+			if err = os.Symlink(core.GetAppExecPath(app), toAppPaths.Symlink); err != nil {
 				return err
 			}
 		}
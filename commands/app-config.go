@@ -1,7 +1,12 @@
 package commands
 
 import (
+	"fmt"
+
+	"github.com/fatih/color"
 	"github.com/urfave/cli/v3"
+	"github.com/zyrouge/pho/core"
+	"github.com/zyrouge/pho/utils"
 )
 
 var AppConfigCommand = cli.Command{
@@ -10,5 +15,27 @@ var AppConfigCommand = cli.Command{
 	Usage:   "Related to application configuration",
 	Commands: []*cli.Command{
 		&AppConfigSetIdCommand,
+		&AppConfigSetDefaultCommand,
+		&AppConfigDesktopCommand,
+		&AppConfigLaunchCommand,
+		&AppConfigPortableCommand,
+		&AppConfigSandboxCommand,
 	},
 }
+
+// The symlink points at the AppImage itself, so only "pho run" and the
+// .desktop file apply the settings of the app config.
+func warnSymlinkLaunch(app *core.AppConfig, settings string) {
+	if app.Paths.Symlink == "" {
+		return
+	}
+	utils.LogWarning(
+		fmt.Sprintf(
+			"%s of %s do not apply when launched through %s, use %s instead",
+			settings,
+			color.CyanString(app.Id),
+			color.CyanString(app.Paths.Symlink),
+			color.CyanString(fmt.Sprintf("pho run %s", app.Id)),
+		),
+	)
+}
@@ -0,0 +1,259 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path"
+	"sort"
+
+	"github.com/fatih/color"
+	"github.com/urfave/cli/v3"
+	"github.com/zyrouge/pho/core"
+	"github.com/zyrouge/pho/utils"
+)
+
+type DoctorCheck struct {
+	Name    string
+	Problem string
+	Fix     string
+}
+
+type doctorTool struct {
+	Name    string
+	Package string
+	Purpose string
+}
+
+var doctorTools = []doctorTool{
+	{
+		Name:    "xdg-desktop-menu",
+		Package: "xdg-utils",
+		Purpose: "install .desktop files",
+	},
+	{
+		Name:    "update-desktop-database",
+		Package: "desktop-file-utils",
+		Purpose: "register mime type handlers",
+	},
+	{
+		Name:    "update-mime-database",
+		Package: "shared-mime-info",
+		Purpose: "register mime types",
+	},
+	{
+		Name:    "gtk-update-icon-cache",
+		Package: "gtk-update-icon-cache",
+		Purpose: "refresh icon caches",
+	},
+}
+
+var DoctorCommand = cli.Command{
+	Name:  "doctor",
+	Usage: "Diagnose the environment pho depends on",
+	Action: func(_ context.Context, cmd *cli.Command) error {
+		checks := []DoctorCheck{}
+		checks = append(checks, checkDoctorFuse()...)
+		checks = append(checks, checkDoctorTools()...)
+
+		utils.LogDebug("reading config")
+		configPath, err := core.GetConfigPath()
+		if err != nil {
+			return err
+		}
+		config, err := core.ReadConfig()
+		if err != nil {
+			checks = append(checks, DoctorCheck{
+				Name:    fmt.Sprintf("config at %s", configPath),
+				Problem: err.Error(),
+				Fix:     fmt.Sprintf("run %s", color.CyanString(fmt.Sprintf("%s init", core.AppExecutableName))),
+			})
+		} else {
+			checks = append(checks, DoctorCheck{
+				Name: fmt.Sprintf("config at %s", configPath),
+			})
+			checks = append(checks, checkDoctorDirs(config)...)
+			checks = append(checks, checkDoctorApps(config)...)
+		}
+		checks = append(checks, checkDoctorTransactions())
+
+		utils.LogLn()
+		problems := 0
+		for _, x := range checks {
+			if x.Problem == "" {
+				utils.LogInfo(fmt.Sprintf("%s %s", utils.LogTickPrefix, x.Name))
+				continue
+			}
+			problems++
+			utils.LogInfo(fmt.Sprintf("%s %s: %s", utils.LogExclamationPrefix, x.Name, color.RedString(x.Problem)))
+			if x.Fix != "" {
+				utils.LogInfo(fmt.Sprintf("  %s %s", utils.LogRightArrowPrefix, x.Fix))
+			}
+		}
+
+		utils.LogLn()
+		if problems > 0 {
+			return fmt.Errorf("found %s problems", color.RedString(fmt.Sprint(problems)))
+		}
+		utils.LogInfo(
+			fmt.Sprintf("%s Everything is working perfectly!", utils.LogTickPrefix),
+		)
+
+		return nil
+	},
+}
+
+func checkDoctorFuse() []DoctorCheck {
+	extractFix := fmt.Sprintf("or install applications with %s", color.CyanString("--extract"))
+	device := DoctorCheck{Name: "fuse device"}
+	if err := core.CheckFuseDevice(); err != nil {
+		device.Problem = err.Error()
+		device.Fix = fmt.Sprintf("load the fuse kernel module (modprobe fuse) %s", extractFix)
+	}
+	fusermount := DoctorCheck{Name: "fusermount"}
+	if !core.HasFusermount() {
+		fusermount.Problem = "fusermount is not installed"
+		fusermount.Fix = fmt.Sprintf("install the fuse package %s", extractFix)
+	}
+	libfuse := DoctorCheck{Name: "libfuse2"}
+	if !core.HasLibFuse2() {
+		libfuse.Problem = "libfuse.so.2 is not installed, older AppImages will not start"
+		libfuse.Fix = fmt.Sprintf("install libfuse2 (libfuse2t64 on newer Ubuntu) %s", extractFix)
+	}
+	return []DoctorCheck{device, fusermount, libfuse}
+}
+
+func checkDoctorTools() []DoctorCheck {
+	checks := []DoctorCheck{}
+	for _, x := range doctorTools {
+		check := DoctorCheck{Name: x.Name}
+		if !utils.HasExecutable(x.Name) {
+			check.Problem = fmt.Sprintf("not installed, unable to %s", x.Purpose)
+			check.Fix = fmt.Sprintf("install the %s package", x.Package)
+		}
+		checks = append(checks, check)
+	}
+	return checks
+}
+
+func checkDoctorDirs(config *core.Config) []DoctorCheck {
+	checks := []DoctorCheck{}
+	dirs := [][]string{
+		{"AppsDir", config.AppsDir},
+		{"DesktopDir", config.DesktopDir},
+		{"SymlinksDir", config.SymlinksDir},
+		{"IconsDir", config.IconsDir},
+		{"MimeDir", config.MimeDir},
+	}
+	for _, x := range dirs {
+		if x[1] == "" {
+			continue
+		}
+		check := DoctorCheck{Name: fmt.Sprintf("%s at %s", x[0], x[1])}
+		if !utils.IsDirWritable(x[1]) {
+			check.Problem = "directory is not writable"
+			check.Fix = "fix the permissions or change the directory in the config"
+		}
+		checks = append(checks, check)
+	}
+
+	if config.SymlinksDir != "" {
+		check := DoctorCheck{Name: "SymlinksDir in PATH"}
+		if !utils.IsDirInPath(config.SymlinksDir) {
+			check.Problem = fmt.Sprintf("%s is not in PATH, linked applications cannot be run by name", config.SymlinksDir)
+			check.Fix = fmt.Sprintf("add %s to your shell profile", color.CyanString(fmt.Sprintf("export PATH=\"%s:$PATH\"", config.SymlinksDir)))
+		}
+		checks = append(checks, check)
+	}
+
+	scannedDirs := []string{}
+	if dataHome, err := utils.GetXdgDataHome(); err == nil {
+		scannedDirs = append(scannedDirs, dataHome)
+	}
+	scannedDirs = append(scannedDirs, utils.GetXdgDataDirs()...)
+	desktop := DoctorCheck{Name: "DesktopDir is scanned by the desktop environment"}
+	scanned := false
+	for _, x := range scannedDirs {
+		if path.Clean(config.DesktopDir) == path.Join(x, "applications") {
+			scanned = true
+			break
+		}
+	}
+	if !scanned {
+		desktop.Problem = fmt.Sprintf("%s is not an applications directory of XDG_DATA_HOME or XDG_DATA_DIRS", config.DesktopDir)
+		desktop.Fix = "use ~/.local/share/applications as DesktopDir or add its parent to XDG_DATA_DIRS"
+	}
+	checks = append(checks, desktop)
+
+	return checks
+}
+
+// Catches the usual causes of "exec format error" and missing icons.
+func checkDoctorApps(config *core.Config) []DoctorCheck {
+	checks := []DoctorCheck{}
+	appIds := []string{}
+	for x := range config.Installed {
+		appIds = append(appIds, x)
+	}
+	sort.Strings(appIds)
+	for _, appId := range appIds {
+		check := DoctorCheck{
+			Name: fmt.Sprintf("application %s", color.CyanString(appId)),
+			Fix:  fmt.Sprintf("run %s", color.CyanString(fmt.Sprintf("%s update --reinstall %s", core.AppExecutableName, appId))),
+		}
+		check.Problem = checkDoctorApp(config, appId)
+		checks = append(checks, check)
+	}
+	return checks
+}
+
+func checkDoctorApp(config *core.Config, appId string) string {
+	app, err := core.ReadAppConfig(core.GetAppConfigPath(config, appId))
+	if err != nil {
+		return err.Error()
+	}
+	arch, err := core.DetectAppImageArch(app.Paths.AppImage)
+	if err != nil {
+		return err.Error()
+	}
+	// applications can be installed for another architecture on purpose
+	targetArch := app.Arch
+	if targetArch == "" {
+		targetArch, _ = core.GetTargetArch(app)
+	}
+	if arch != "" && targetArch != "" && arch != targetArch {
+		return fmt.Sprintf("appimage is built for %s but %s was expected", arch, targetArch)
+	}
+	if _, err = os.Stat(core.GetAppExecPath(app)); err != nil {
+		return err.Error()
+	}
+	// the paths are empty when the appimage has no icon or is headless
+	if app.Paths.Icon != "" {
+		if _, err = os.Stat(app.Paths.Icon); err != nil {
+			return "icon is missing"
+		}
+	}
+	if app.Paths.Desktop != "" {
+		if _, err = os.Stat(app.Paths.Desktop); err != nil {
+			return ".desktop file is missing"
+		}
+	}
+	return ""
+}
+
+func checkDoctorTransactions() DoctorCheck {
+	check := DoctorCheck{Name: "pending transactions"}
+	if transactionsPath, err := core.GetTransactionsPath(); err == nil {
+		check.Name = fmt.Sprintf("pending transactions at %s", transactionsPath)
+	}
+	transactions, err := core.GetTransactions()
+	if err != nil {
+		check.Problem = err.Error()
+		return check
+	}
+	if count := len(transactions.PendingInstallations); count > 0 {
+		check.Problem = fmt.Sprintf("%d installations did not complete", count)
+		check.Fix = fmt.Sprintf("run %s", color.CyanString(fmt.Sprintf("%s tidy-broken", core.AppExecutableName)))
+	}
+	return check
+}
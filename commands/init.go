@@ -3,6 +3,7 @@ package commands
 import (
 	"bufio"
 	"context"
+	"errors"
 	"fmt"
 	"os"
 	"path"
@@ -29,10 +30,24 @@ var InitCommand = cli.Command{
 			Name:  "apps-link-dir",
 			Usage: "AppImage symlinks directory",
 		},
+		&cli.StringFlag{
+			Name:  "apps-icons-dir",
+			Usage: "Icon themes directory",
+		},
 		&cli.BoolFlag{
 			Name:  "enable-integration-prompt",
 			Usage: "Enables AppImageLauncher's integration prompt",
 		},
+		&cli.StringFlag{
+			Name:  "signature-policy",
+			Usage: "Handling of invalid signatures (ignore, warn, require), only require rejects unsigned AppImages",
+			Value: string(core.SignaturePolicyWarn),
+		},
+		&cli.IntFlag{
+			Name:  "keep-versions",
+			Usage: "Number of previous versions kept for rollbacks",
+			Value: core.DefaultKeepVersions,
+		},
 		&cli.BoolFlag{
 			Name:  "overwrite",
 			Usage: "Overwrite config if exists",
@@ -47,16 +62,34 @@ var InitCommand = cli.Command{
 		appsDir := cmd.String("apps-dir")
 		appsDesktopDir := cmd.String("apps-desktop-dir")
 		appsLinkDir := cmd.String("apps-link-dir")
+		appsIconsDir := cmd.String("apps-icons-dir")
 		enableIntegrationPromptSet, enableIntegrationPrompt := utils.CommandBoolSetAndValue(cmd, "enable-integration-prompt")
+		signaturePolicyValue := cmd.String("signature-policy")
+		keepVersions := cmd.Int("keep-versions")
 		overwrite := cmd.Bool("overwrite")
 		assumeYes := cmd.Bool("assume-yes")
 		utils.LogDebug(fmt.Sprintf("argument apps-dir: %s", appsDir))
 		utils.LogDebug(fmt.Sprintf("argument apps-desktop-dir: %s", appsDesktopDir))
 		utils.LogDebug(fmt.Sprintf("argument apps-link-dir: %s", appsLinkDir))
+		utils.LogDebug(fmt.Sprintf("argument apps-icons-dir: %s", appsIconsDir))
 		utils.LogDebug(fmt.Sprintf("argument enable-integration-prompt: %v", enableIntegrationPrompt))
+		utils.LogDebug(fmt.Sprintf("argument signature-policy: %s", signaturePolicyValue))
+		utils.LogDebug(fmt.Sprintf("argument keep-versions: %d", keepVersions))
 		utils.LogDebug(fmt.Sprintf("argument overwrite: %v", overwrite))
 		utils.LogDebug(fmt.Sprintf("argument assume-yes: %v", assumeYes))
 
+		signaturePolicy, err := core.ParseSignaturePolicy(signaturePolicyValue)
+		if err != nil {
+			return err
+		}
+		if keepVersions < 0 {
+			return errors.New("invalid number of versions to keep")
+		}
+
+		if err := core.CheckSystemPermissions(); err != nil {
+			return err
+		}
+
 		reader := bufio.NewReader(os.Stdin)
 		configPath, err := core.GetConfigPath()
 		if err != nil {
@@ -88,13 +121,11 @@ var InitCommand = cli.Command{
 			}
 		}
 
-		homeDir, err := os.UserHomeDir()
-		if err != nil {
-			return err
-		}
-
 		if appsDir == "" {
-			appsDir = path.Join(homeDir, ".local/share", core.AppCodeName, "applications")
+			appsDir, err = core.GetDefaultAppsDir()
+			if err != nil {
+				return err
+			}
 			if !assumeYes {
 				appsDir, err = utils.PromptTextInput(
 					reader,
@@ -112,7 +143,10 @@ var InitCommand = cli.Command{
 		}
 
 		if appsDesktopDir == "" {
-			appsDesktopDir = path.Join(homeDir, ".local/share", "applications")
+			appsDesktopDir, err = core.GetDefaultDesktopDir()
+			if err != nil {
+				return err
+			}
 			if !assumeYes {
 				appsDesktopDir, err = utils.PromptTextInput(
 					reader,
@@ -140,7 +174,10 @@ var InitCommand = cli.Command{
 			}
 		}
 		if enableAppsLinkDir && appsLinkDir == "" {
-			appsLinkDir = path.Join(homeDir, ".local/bin")
+			appsLinkDir, err = core.GetDefaultSymlinksDir()
+			if err != nil {
+				return err
+			}
 			if !assumeYes {
 				appsLinkDir, err = utils.PromptTextInput(
 					reader,
@@ -159,6 +196,22 @@ var InitCommand = cli.Command{
 			}
 		}
 
+		if appsIconsDir == "" {
+			appsIconsDir, err = core.GetDefaultIconsDir()
+			if err != nil {
+				return err
+			}
+		}
+		appsIconsDir, err = utils.ResolvePath(appsIconsDir)
+		if err != nil {
+			return err
+		}
+
+		appsMimeDir, err := core.GetDefaultMimeDir()
+		if err != nil {
+			return err
+		}
+
 		if !enableIntegrationPromptSet && !assumeYes {
 			enableIntegrationPrompt, err = utils.PromptYesNoInput(
 				reader,
@@ -172,12 +225,24 @@ var InitCommand = cli.Command{
 		utils.LogLn()
 		summary := utils.NewLogTable()
 		summary.Add(utils.LogRightArrowPrefix, "Configuration file", color.CyanString(configPath))
+		if core.SystemMode {
+			summary.Add(utils.LogRightArrowPrefix, "Installed for", color.CyanString("all users"))
+		}
+		if profile := core.GetProfile(); profile != "" {
+			summary.Add(utils.LogRightArrowPrefix, "Profile", color.CyanString(profile))
+		}
 		summary.Add(utils.LogRightArrowPrefix, "AppImages directory", color.CyanString(appsDir))
 		summary.Add(utils.LogRightArrowPrefix, ".desktop files directory", color.CyanString(appsDesktopDir))
 		if enableAppsLinkDir {
 			summary.Add(utils.LogRightArrowPrefix, "AppImages symlink directory", color.CyanString(appsLinkDir))
 		}
+		summary.Add(utils.LogRightArrowPrefix, "Icons directory", color.CyanString(appsIconsDir))
 		summary.Add(utils.LogRightArrowPrefix, "Enable AppImageLauncher's integration prompt?", color.CyanString(utils.BoolToYesNo(enableIntegrationPrompt)))
+		summary.Add(utils.LogRightArrowPrefix, "Signature policy", color.CyanString(string(signaturePolicy)))
+		summary.Add(utils.LogRightArrowPrefix, "Kept versions", color.CyanString(fmt.Sprint(keepVersions)))
+		if core.ArchOverride != "" {
+			summary.Add(utils.LogRightArrowPrefix, "Target architecture", color.CyanString(core.ArchOverride))
+		}
 		summary.Print()
 		utils.LogLn()
 
@@ -192,13 +257,13 @@ var InitCommand = cli.Command{
 			}
 		}
 
-		if err := os.MkdirAll(path.Dir(configPath), os.ModePerm); err != nil {
+		if err := os.MkdirAll(path.Dir(configPath), utils.DirPermissions); err != nil {
 			return err
 		}
-		if err := os.MkdirAll(path.Dir(appsDir), os.ModePerm); err != nil {
+		if err := os.MkdirAll(path.Dir(appsDir), utils.DirPermissions); err != nil {
 			return err
 		}
-		if err := os.MkdirAll(path.Dir(appsDesktopDir), os.ModePerm); err != nil {
+		if err := os.MkdirAll(path.Dir(appsDesktopDir), utils.DirPermissions); err != nil {
 			return err
 		}
 		config := &core.Config{
@@ -207,7 +272,17 @@ var InitCommand = cli.Command{
 			Installed:               map[string]string{},
 			EnableIntegrationPrompt: enableIntegrationPrompt,
 			SymlinksDir:             appsLinkDir,
+			IconsDir:                appsIconsDir,
+			MimeDir:                 appsMimeDir,
+			SignaturePolicy:         signaturePolicy,
+			Arch:                    core.ArchOverride,
+			KeepVersions:            int(keepVersions),
+		}
+		unlock, err := core.LockState()
+		if err != nil {
+			return err
 		}
+		defer unlock()
 		err = core.SaveConfig(config)
 		if err != nil {
 			return err
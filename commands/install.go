@@ -1,11 +1,17 @@
 package commands
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
 	"fmt"
 	"io"
 	"os"
+	"os/signal"
 	"path"
 	"slices"
+	"sync/atomic"
+	"syscall"
 	"time"
 
 	"github.com/fatih/color"
@@ -56,6 +62,11 @@ type InstallableApp struct {
 	PrintCycle     int
 	SkipCycleErase bool
 	Status         InstallableAppStatus
+	Interrupted    *atomic.Bool
+
+	// downloaded appimage that waits for the state lock
+	stagedPath string
+	pending    *core.PendingInstallation
 }
 
 type InstallableAppRawProgress struct {
@@ -64,6 +75,9 @@ type InstallableAppRawProgress struct {
 }
 
 func (x *InstallableApp) Write(data []byte) (n int, err error) {
+	if err := x.checkInterrupted(); err != nil {
+		return 0, err
+	}
 	l := len(data)
 	now := utils.TimeNowSeconds()
 	x.Progress += int64(l)
@@ -90,6 +104,11 @@ func (x *InstallableApp) logDebug(msg string) {
 	}
 }
 
+func (x *InstallableApp) logWarning(msg string) {
+	x.SkipCycleErase = true
+	utils.LogWarning(msg)
+}
+
 func (x *InstallableApp) PrintStatus() {
 	if x.PrintCycle > 0 && !x.SkipCycleErase {
 		utils.TerminalErasePreviousLine()
@@ -167,7 +186,12 @@ func (x *InstallableApp) StartStatusTicker() *time.Ticker {
 	return ticker
 }
 
+var errInstallInterrupted = errors.New("installation was interrupted")
+
 func InstallApps(apps []InstallableApp) (int, int) {
+	interrupted := &atomic.Bool{}
+	stopInterrupts := handleInstallInterrupts(interrupted)
+	defer stopInterrupts()
 	success := 0
 	count := len(apps)
 	for i := range apps {
@@ -180,114 +204,586 @@ func InstallApps(apps []InstallableApp) (int, int) {
 			Sizes: []int{},
 			Times: []int64{},
 		}
+		x.Interrupted = interrupted
 		x.PrintStatus()
-		x.logDebug("updating transactions")
-		core.UpdateTransactions(func(transactions *core.Transactions) error {
-			transactions.PendingInstallations[x.App.Id] = core.PendingInstallation{
-				InvolvedDirs:  []string{x.App.Paths.Dir},
-				InvolvedFiles: []string{x.App.Paths.Desktop},
-			}
-			return nil
-		})
-		if err := x.Install(); err != nil {
+		ticker := x.StartStatusTicker()
+		err := x.Download()
+		// held until the transaction is committed or rolled back, so that
+		// other processes can neither modify the same files nor roll back
+		// the installation while it is in progress
+		var unlock func()
+		if err == nil {
+			unlock, err = core.LockState()
+		}
+		if err == nil {
+			x.pending, err = x.BeginTransaction()
+		}
+		if err == nil {
+			err = x.Install()
+		}
+		ticker.Stop()
+		x.removeStagedAppImage()
+		if err != nil {
 			x.Status = InstallableAppFailed
 			x.PrintStatus()
 			utils.LogError(err)
+			if x.pending != nil {
+				x.RollbackTransaction(x.pending)
+			}
+			if unlock != nil {
+				unlock()
+			}
 			break
-		} else {
-			x.Status = InstallableAppInstalled
-			x.PrintStatus()
-			success++
 		}
-		x.logDebug("updating transactions")
-		core.UpdateTransactions(func(transactions *core.Transactions) error {
-			delete(transactions.PendingInstallations, x.App.Id)
-			return nil
-		})
+		x.Status = InstallableAppInstalled
+		x.PrintStatus()
+		success++
+		x.CommitTransaction(x.pending)
+		unlock()
 	}
 	return success, count - success
 }
 
+// The first interrupt stops the installation at the next step so that it
+// can be rolled back, the default behaviour is restored for the next ones.
+func handleInstallInterrupts(interrupted *atomic.Bool) func() {
+	signals := make(chan os.Signal, 1)
+	done := make(chan struct{})
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		select {
+		case <-signals:
+			interrupted.Store(true)
+			signal.Stop(signals)
+
+		case <-done:
+		}
+	}()
+	return func() {
+		signal.Stop(signals)
+		close(done)
+	}
+}
+
+func (x *InstallableApp) checkInterrupted() error {
+	if x.Interrupted != nil && x.Interrupted.Load() {
+		return errInstallInterrupted
+	}
+	return nil
+}
+
+// Records the installation before anything is modified. The files of an
+// installed application are backed up, so that a failure restores them
+// instead of removing the application.
+func (x *InstallableApp) BeginTransaction() (*core.PendingInstallation, error) {
+	transactions, err := core.GetTransactions()
+	if err != nil {
+		return nil, err
+	}
+	if previous, ok := transactions.PendingInstallations[x.App.Id]; ok {
+		x.logDebug("rolling back previous incomplete installation")
+		if err = previous.Rollback(); err != nil {
+			return nil, err
+		}
+	}
+	config, err := core.GetConfig()
+	if err != nil {
+		return nil, err
+	}
+	pending := &core.PendingInstallation{
+		InvolvedDirs:   []string{},
+		InvolvedFiles:  []string{},
+		PreservedFiles: []string{},
+	}
+	if appConfigPath, ok := config.Installed[x.App.Id]; ok {
+		paths := &x.App.Paths
+		if previous, err := core.ReadAppConfig(appConfigPath); err == nil {
+			paths = &previous.Paths
+		}
+		x.logDebug(fmt.Sprintf("backing up %s", paths.Dir))
+		if pending.Backup, err = core.BackupApp(paths); err != nil {
+			return nil, err
+		}
+	} else {
+		pending.InvolvedDirs = append(pending.InvolvedDirs, x.App.Paths.Dir)
+		files := core.GetAppExternalFiles(&x.App.Paths)
+		if config.SymlinksDir != "" {
+			// headless applications are always linked
+			files = append(files, path.Join(config.SymlinksDir, x.App.Id))
+		}
+		for _, file := range files {
+			if _, err := os.Lstat(file); err == nil && !core.IsSymlinkInto(file, x.App.Paths.Dir) {
+				// symlinks are named after the id in every profile
+				if file == x.App.Paths.Symlink {
+					return nil, fmt.Errorf("%s already exists and does not belong to %s", file, x.App.Id)
+				}
+				pending.PreservedFiles = append(pending.PreservedFiles, file)
+			}
+		}
+		pending.AddInvolvedFiles(core.GetAppExternalFiles(&x.App.Paths))
+	}
+	x.logDebug("updating transactions")
+	err = core.UpdateTransactions(func(transactions *core.Transactions) error {
+		transactions.PendingInstallations[x.App.Id] = *pending
+		return nil
+	})
+	if err != nil {
+		pending.Commit()
+		return nil, err
+	}
+	return pending, nil
+}
+
+// Records the files in the pending installation before they are created, so
+// that they are rolled back even when pho gets killed. Existing files are
+// either backed up or do not belong to the application.
+func (x *InstallableApp) involveFiles(files []string) error {
+	created := []string{}
+	for _, file := range files {
+		if file == "" {
+			continue
+		}
+		if _, err := os.Lstat(file); errors.Is(err, os.ErrNotExist) {
+			created = append(created, file)
+		}
+	}
+	count := len(x.pending.InvolvedFiles)
+	x.pending.AddInvolvedFiles(created)
+	if len(x.pending.InvolvedFiles) == count {
+		return nil
+	}
+	x.logDebug("updating transactions")
+	return core.UpdateTransactions(func(transactions *core.Transactions) error {
+		transactions.PendingInstallations[x.App.Id] = *x.pending
+		return nil
+	})
+}
+
+func (x *InstallableApp) RollbackTransaction(pending *core.PendingInstallation) {
+	// includes the files created by the failed installation
+	pending.AddInvolvedFiles(core.GetAppExternalFiles(&x.App.Paths))
+	x.logDebug("rolling back installation")
+	if err := pending.Rollback(); err != nil {
+		utils.LogError(err)
+		utils.LogWarning(
+			fmt.Sprintf(
+				"run %s to retry the rollback",
+				color.CyanString(fmt.Sprintf("%s tidy-broken", core.AppExecutableName)),
+			),
+		)
+		return
+	}
+	if pending.Backup != nil {
+		utils.LogInfo(
+			fmt.Sprintf(
+				"%s Restored the previous installation of %s",
+				utils.LogRightArrowPrefix,
+				color.CyanString(x.App.Id),
+			),
+		)
+	}
+	x.logDebug("updating transactions")
+	core.UpdateTransactions(func(transactions *core.Transactions) error {
+		delete(transactions.PendingInstallations, x.App.Id)
+		return nil
+	})
+}
+
+func (x *InstallableApp) CommitTransaction(pending *core.PendingInstallation) {
+	if pending.Backup != nil {
+		x.logDebug("keeping previous version")
+		if err := x.keepPreviousVersion(pending.Backup); err != nil {
+			x.logDebug(fmt.Sprintf("unable to keep previous version: %v", err))
+		}
+	}
+	x.logDebug("removing backup")
+	if err := pending.Commit(); err != nil {
+		x.logDebug(fmt.Sprintf("unable to remove backup: %v", err))
+	}
+	x.logDebug("updating transactions")
+	core.UpdateTransactions(func(transactions *core.Transactions) error {
+		delete(transactions.PendingInstallations, x.App.Id)
+		return nil
+	})
+}
+
+func (x *InstallableApp) keepPreviousVersion(backup *core.AppBackup) error {
+	config, err := core.GetConfig()
+	if err != nil {
+		return err
+	}
+	return core.KeepAppVersion(x.App, backup, config.KeepVersions)
+}
+
+// Installs the downloaded appimage, the state lock is held and the
+// transaction has begun.
 func (x *InstallableApp) Install() error {
-	ticker := x.StartStatusTicker()
-	defer ticker.Stop()
-	if err := x.Download(); err != nil {
+	if err := x.InheritPreviousConfig(); err != nil {
+		return err
+	}
+	if err := x.VerifySignature(x.stagedPath); err != nil {
+		return err
+	}
+	if err := x.placeAppImage(); err != nil {
+		return err
+	}
+	if err := x.checkInterrupted(); err != nil {
 		return err
 	}
+	if !x.App.Extracted {
+		if err := core.CheckFuseAvailable(x.App.Paths.AppImage); err != nil {
+			x.logDebug(fmt.Sprintf("installing as extracted appdir as fuse is unavailable: %v", err))
+			x.App.Extracted = true
+		}
+	}
 	x.Status = InstallableAppIntegrating
 	if err := x.Integrate(); err != nil {
 		return err
 	}
+	if err := x.checkInterrupted(); err != nil {
+		return err
+	}
 	if err := x.SaveConfig(); err != nil {
 		return err
 	}
 	return nil
 }
 
-func (x *InstallableApp) Download() error {
-	x.logDebug(fmt.Sprintf("creating %s", x.App.Paths.Dir))
-	if err := os.MkdirAll(x.App.Paths.Dir, os.ModePerm); err != nil {
+// Carries over user customizations when re-installing an application.
+func (x *InstallableApp) InheritPreviousConfig() error {
+	config, err := core.GetConfig()
+	if err != nil {
 		return err
 	}
-	x.logDebug(fmt.Sprintf("creating %s", x.App.Paths.Desktop))
-	if err := os.MkdirAll(path.Dir(x.App.Paths.Desktop), os.ModePerm); err != nil {
+	appConfigPath, ok := config.Installed[x.App.Id]
+	if !ok {
+		return nil
+	}
+	x.logDebug(fmt.Sprintf("reading previous app config from %s", appConfigPath))
+	previous, err := core.ReadAppConfig(appConfigPath)
+	if err != nil {
+		x.logDebug(fmt.Sprintf("unable to read previous app config: %v", err))
+		return nil
+	}
+	if x.App.Channel == "" {
+		x.App.BaseId = previous.BaseId
+		x.App.Channel = previous.Channel
+	}
+	x.App.MimeDefaults = previous.MimeDefaults
+	x.App.DesktopOverrides = previous.DesktopOverrides
+	x.App.Launch = previous.Launch
+	x.App.Portable = previous.Portable
+	x.App.Sandbox = previous.Sandbox
+	x.App.Extracted = x.App.Extracted || previous.Extracted
+	x.App.SignerFingerprint = previous.SignerFingerprint
+	// tracked so that stale files get removed while integrating
+	x.App.Paths.Icons = previous.Paths.Icons
+	x.App.Paths.MimePackages = previous.Paths.MimePackages
+	return nil
+}
+
+// Downloads and verifies the appimage next to the application directory,
+// without holding the state lock.
+func (x *InstallableApp) Download() error {
+	appsDir := path.Dir(x.App.Paths.Dir)
+	x.logDebug(fmt.Sprintf("creating %s", appsDir))
+	if err := os.MkdirAll(appsDir, utils.DirPermissions); err != nil {
 		return err
 	}
-	tempFile, err := utils.CreateTempFile(x.App.Paths.AppImage)
+	tempFile, err := utils.CreateTempFile(x.App.Paths.Dir + path.Ext(x.App.Paths.AppImage))
 	if err != nil {
 		return err
 	}
-	x.logDebug(fmt.Sprintf("created %s", tempFile.Name()))
 	defer tempFile.Close()
+	x.stagedPath = tempFile.Name()
+	x.logDebug(fmt.Sprintf("created %s", x.stagedPath))
 	data, err := x.Asset.Download()
 	if err != nil {
 		return err
 	}
 	defer data.Close()
-	mw := io.MultiWriter(tempFile, x)
+	hash := sha256.New()
+	mw := io.MultiWriter(tempFile, x, hash)
 	_, err = io.Copy(mw, data)
 	if err != nil {
 		return err
 	}
-	x.logDebug(fmt.Sprintf("renaming %s to %s", tempFile.Name(), x.App.Paths.AppImage))
-	if err = os.Rename(tempFile.Name(), x.App.Paths.AppImage); err != nil {
+	digest := hex.EncodeToString(hash.Sum(nil))
+	x.logDebug(fmt.Sprintf("downloaded sha256: %s", digest))
+	if x.Asset.Sha256 != "" && x.Asset.Sha256 != digest {
+		return fmt.Errorf(
+			"sha256 mismatch, expected %s but got %s",
+			x.Asset.Sha256,
+			digest,
+		)
+	}
+	if x.Asset.Signature != nil {
+		x.logDebug("verifying detached signature")
+		if err = x.Asset.VerifySignature(x.stagedPath); err != nil {
+			return err
+		}
+	}
+	if err = x.VerifyArch(x.stagedPath); err != nil {
+		return err
+	}
+	x.App.Sha256 = digest
+	return nil
+}
+
+func (x *InstallableApp) placeAppImage() error {
+	x.logDebug(fmt.Sprintf("creating %s", x.App.Paths.Dir))
+	if err := os.MkdirAll(x.App.Paths.Dir, utils.DirPermissions); err != nil {
+		return err
+	}
+	if x.App.Paths.Desktop != "" {
+		x.logDebug(fmt.Sprintf("creating %s", x.App.Paths.Desktop))
+		if err := os.MkdirAll(path.Dir(x.App.Paths.Desktop), utils.DirPermissions); err != nil {
+			return err
+		}
+	}
+	x.logDebug(fmt.Sprintf("renaming %s to %s", x.stagedPath, x.App.Paths.AppImage))
+	if err := os.Rename(x.stagedPath, x.App.Paths.AppImage); err != nil {
 		return err
 	}
+	x.stagedPath = ""
 	x.logDebug(fmt.Sprintf("changing permissions of %s", x.App.Paths.AppImage))
 	return os.Chmod(x.App.Paths.AppImage, 0755)
 }
 
+func (x *InstallableApp) removeStagedAppImage() {
+	if x.stagedPath == "" {
+		return
+	}
+	x.logDebug(fmt.Sprintf("removing %s", x.stagedPath))
+	os.Remove(x.stagedPath)
+	x.stagedPath = ""
+}
+
+// Asset names are only a hint, the ELF header of the runtime tells the actual
+// architecture.
+func (x *InstallableApp) VerifyArch(name string) error {
+	arch, err := core.DetectAppImageArch(name)
+	if err != nil {
+		return err
+	}
+	targetArch, err := core.GetTargetArch(x.App)
+	if err != nil {
+		return err
+	}
+	x.logDebug(fmt.Sprintf("appimage arch: %s, target arch: %s", arch, targetArch))
+	if arch == "" {
+		x.logWarning(fmt.Sprintf("%s: unable to determine architecture compatibility", x.App.Id))
+		return nil
+	}
+	if arch != targetArch {
+		return fmt.Errorf(
+			"appimage is built for %s but the target is %s",
+			color.CyanString(arch),
+			color.CyanString(targetArch),
+		)
+	}
+	x.App.Arch = arch
+	return nil
+}
+
+// The previously recorded signer is pinned, so that an update signed by a
+// different key is treated like an invalid signature.
+func (x *InstallableApp) VerifySignature(name string) error {
+	config, err := core.GetConfig()
+	if err != nil {
+		return err
+	}
+	if config.SignaturePolicy == core.SignaturePolicyIgnore {
+		return nil
+	}
+	x.logDebug(fmt.Sprintf("verifying signature of %s", name))
+	fingerprint, err := core.VerifyAppImageSignature(name)
+	if err == nil && x.App.SignerFingerprint != "" && x.App.SignerFingerprint != fingerprint {
+		err = fmt.Errorf(
+			"appimage is signed by %s instead of %s",
+			fingerprint,
+			x.App.SignerFingerprint,
+		)
+	}
+	if err != nil {
+		if config.SignaturePolicy == core.SignaturePolicyRequire {
+			return err
+		}
+		// most appimages are not signed at all, warning about every one of
+		// them would only bury the warnings about invalid signatures
+		if errors.Is(err, core.ErrAppImageUnsigned) && x.App.SignerFingerprint == "" {
+			x.logDebug(fmt.Sprintf("%s: %v", x.App.Id, err))
+			return nil
+		}
+		x.logWarning(fmt.Sprintf("%s: %v", x.App.Id, err))
+		// the pinned signer is kept, so that later updates warn again
+		return nil
+	}
+	if fingerprint != "" {
+		x.App.SignerFingerprint = fingerprint
+	}
+	return nil
+}
+
 func (x *InstallableApp) Integrate() error {
 	tempDir := path.Join(x.App.Paths.Dir, "temp")
+	if err := x.involveFiles([]string{tempDir}); err != nil {
+		return err
+	}
 	x.logDebug(fmt.Sprintf("creating %s", tempDir))
-	err := os.Mkdir(tempDir, os.ModePerm)
+	err := os.Mkdir(tempDir, utils.DirPermissions)
 	if err != nil {
 		return err
 	}
+	defer os.RemoveAll(tempDir)
 	x.logDebug(fmt.Sprintf("deflating %s into %s", x.App.Paths.AppImage, tempDir))
 	deflated, err := core.DeflateAppImage(x.App.Paths.AppImage, tempDir)
 	if err != nil {
 		return err
 	}
-	defer os.RemoveAll(tempDir)
 	metadata, err := deflated.ExtractMetadata()
 	if err != nil {
 		return err
 	}
-	x.logDebug(fmt.Sprintf("creating %s", x.App.Paths.Icon))
-	if err = metadata.CopyIconFile(&x.App.Paths); err != nil {
+	x.logDebug(fmt.Sprintf("saving metainfo to %s", x.App.Paths.Metainfo))
+	if err = metadata.SaveMetainfo(&x.App.Paths); err != nil {
+		// metainfo is informational, broken files should not fail the installation
+		x.logDebug(fmt.Sprintf("unable to save metainfo: %v", err))
+	}
+	config, err := core.GetConfig()
+	if err != nil {
 		return err
 	}
-	x.logDebug(fmt.Sprintf("installing .desktop file at %s", x.App.Paths.Desktop))
-	if err = metadata.InstallDesktopFile(&x.App.Paths); err != nil {
+	x.App.Headless = metadata.Headless
+	if x.App.Headless {
+		err = x.integrateHeadless(config)
+	} else {
+		err = x.integrateDesktop(config, metadata)
+	}
+	if err != nil {
 		return err
 	}
 	if x.App.Paths.Symlink != "" {
+		if err = x.involveFiles([]string{x.App.Paths.Symlink}); err != nil {
+			return err
+		}
 		x.logDebug(fmt.Sprintf("creating symlink %s", x.App.Paths.Symlink))
-		if err = metadata.Symlink(&x.App.Paths); err != nil {
+		if err = metadata.Symlink(x.App); err != nil {
 			return err
 		}
 	}
+	appDir := core.GetAppDirPath(&x.App.Paths)
+	if x.App.Extracted {
+		x.logDebug(fmt.Sprintf("moving %s to %s", metadata.AppDir, appDir))
+		return metadata.KeepAppDir(&x.App.Paths)
+	}
+	x.logDebug(fmt.Sprintf("removing %s", appDir))
+	return os.RemoveAll(appDir)
+}
+
+func (x *InstallableApp) integrateDesktop(config *core.Config, metadata *core.DeflatedAppImageMetadata) error {
+	paths := core.ConstructAppPaths(config, x.App.Id, &core.ConstructAppPathsOptions{})
+	if x.App.Paths.Desktop == "" {
+		x.App.Paths.Desktop = paths.Desktop
+		x.App.Paths.DesktopTemplate = paths.DesktopTemplate
+	}
+	if x.App.Paths.Icon == "" {
+		x.App.Paths.Icon = paths.Icon
+	}
+	iconPath, err := metadata.GetIconFilePath(&x.App.Paths)
+	if err != nil {
+		return err
+	}
+	themeIcons, err := metadata.GetThemeIcons()
+	if err != nil {
+		return err
+	}
+	iconName := core.ConstructAppIconName(x.App.Id)
+	files := []string{x.App.Paths.Desktop, x.App.Paths.DesktopTemplate}
+	if iconPath != "" {
+		files = append(files, iconPath)
+	}
+	for _, icon := range themeIcons {
+		files = append(files, core.ConstructThemeIconPath(config.IconsDir, iconName, icon))
+	}
+	for _, name := range metadata.MimePackages {
+		files = append(files, core.ConstructAppMimePackagePath(config.MimeDir, x.App.Id, name))
+	}
+	if err = x.involveFiles(files); err != nil {
+		return err
+	}
+	x.logDebug(fmt.Sprintf("creating %s", iconPath))
+	if err = metadata.CopyIconFile(&x.App.Paths); err != nil {
+		return err
+	}
+	x.logDebug(fmt.Sprintf("installing icons into %s", config.IconsDir))
+	if err = core.InstallThemeIcons(&x.App.Paths, config.IconsDir, iconName, themeIcons); err != nil {
+		return err
+	}
+	x.logDebug("refreshing icon cache")
+	if err := core.RefreshIconCache(config.IconsDir); err != nil {
+		x.logDebug(fmt.Sprintf("unable to refresh icon cache: %v", err))
+	}
+	x.logDebug(fmt.Sprintf("installing .desktop file at %s", x.App.Paths.Desktop))
+	if err := metadata.InstallDesktopFile(x.App); err != nil {
+		return err
+	}
+	x.logDebug(fmt.Sprintf("installing mime packages into %s", config.MimeDir))
+	if err := core.InstallMimePackages(&x.App.Paths, config.MimeDir, x.App.Id, metadata.MimePackages); err != nil {
+		return err
+	}
+	x.logDebug("updating mime database")
+	if err := core.UpdateMimeDatabase(config.MimeDir); err != nil {
+		x.logDebug(fmt.Sprintf("unable to update mime database: %v", err))
+	}
+	x.logDebug("updating desktop database")
+	if err := core.UpdateDesktopDatabase(path.Dir(x.App.Paths.Desktop)); err != nil {
+		x.logDebug(fmt.Sprintf("unable to update desktop database: %v", err))
+	}
+	return nil
+}
+
+// Command line applications are only reachable through their symlink, any
+// desktop integration left by a previous version is removed.
+func (x *InstallableApp) integrateHeadless(config *core.Config) error {
+	if x.App.Paths.Desktop != "" {
+		if _, err := os.Stat(x.App.Paths.Desktop); err == nil {
+			x.logDebug(fmt.Sprintf("removing %s", x.App.Paths.Desktop))
+			if err = core.UninstallDesktopFile(x.App.Paths.Desktop); err != nil {
+				return err
+			}
+		}
+	}
+	if x.App.Paths.Icon != "" {
+		x.logDebug(fmt.Sprintf("removing %s", x.App.Paths.Icon))
+		if err := os.Remove(x.App.Paths.Icon); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+	}
+	if x.App.Paths.DesktopTemplate != "" {
+		x.logDebug(fmt.Sprintf("removing %s", x.App.Paths.DesktopTemplate))
+		if err := os.Remove(x.App.Paths.DesktopTemplate); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+	}
+	x.logDebug("removing icons")
+	if err := core.UninstallThemeIcons(&x.App.Paths); err != nil {
+		return err
+	}
+	x.logDebug("removing mime packages")
+	if err := core.UninstallMimePackages(&x.App.Paths); err != nil {
+		return err
+	}
+	x.App.Paths.Desktop = ""
+	x.App.Paths.DesktopTemplate = ""
+	x.App.Paths.Icon = ""
+	if x.App.Paths.Symlink == "" {
+		if config.SymlinksDir == "" {
+			x.logWarning(fmt.Sprintf("%s has no desktop entry and no symlinks directory is configured, it can only be launched using %s run", x.App.Id, core.AppExecutableName))
+			return nil
+		}
+		x.App.Paths.Symlink = path.Join(config.SymlinksDir, x.App.Id)
+	}
 	return nil
 }
 
@@ -300,13 +796,11 @@ func (x *InstallableApp) SaveConfig() error {
 	if err := core.SaveSourceConfig[any](x.App.Paths.SourceConfig, x.Source); err != nil {
 		return err
 	}
-	config, err := core.ReadConfig()
-	if err != nil {
-		return err
-	}
-	config.Installed[x.App.Id] = x.App.Paths.Config
 	x.logDebug("saving config")
-	return core.SaveConfig(config)
+	return core.UpdateConfig(func(config *core.Config) error {
+		config.Installed[x.App.Id] = x.App.Paths.Config
+		return nil
+	})
 }
 
 func prettyBytes(size int64) string {
@@ -6,6 +6,7 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"path"
 	"strings"
 
 	"github.com/fatih/color"
@@ -47,11 +48,23 @@ var InstallGithubCommand = cli.Command{
 				core.GithubSourceReleaseTagged,
 			),
 		},
+		&cli.StringFlag{
+			Name:  "channel",
+			Usage: "Installs into a separate slot, so that several channels (stable, beta) can coexist",
+		},
 		&cli.BoolFlag{
 			Name:    "link",
 			Aliases: []string{"l"},
 			Usage:   "Creates a symlink",
 		},
+		&cli.StringFlag{
+			Name:  "signature-key",
+			Usage: "Public key file (OpenPGP, minisign or cosign) that must have signed the AppImage",
+		},
+		&cli.BoolFlag{
+			Name:  "extract",
+			Usage: "Launch from the extracted AppDir instead of mounting the AppImage",
+		},
 		&cli.BoolFlag{
 			Name:    "assume-yes",
 			Aliases: []string{"y"},
@@ -78,13 +91,19 @@ var InstallGithubCommand = cli.Command{
 		appId := cmd.String("id")
 		releaseType := cmd.String("release")
 		tagName := cmd.String("tag")
+		channel := cmd.String("channel")
 		link := cmd.Bool("link")
+		extract := cmd.Bool("extract")
+		signatureKey := cmd.String("signature-key")
 		assumeYes := cmd.Bool("assume-yes")
 		utils.LogDebug(fmt.Sprintf("argument url: %s", url))
 		utils.LogDebug(fmt.Sprintf("argument id: %s", appId))
 		utils.LogDebug(fmt.Sprintf("argument release: %v", releaseType))
 		utils.LogDebug(fmt.Sprintf("argument tag: %v", tagName))
+		utils.LogDebug(fmt.Sprintf("argument channel: %s", channel))
 		utils.LogDebug(fmt.Sprintf("argument link: %v", link))
+		utils.LogDebug(fmt.Sprintf("argument extract: %v", extract))
+		utils.LogDebug(fmt.Sprintf("argument signature-key: %s", signatureKey))
 		utils.LogDebug(fmt.Sprintf("argument assume-yes: %v", assumeYes))
 
 		isValidUrl, ghUsername, ghReponame := core.ParseGithubRepoUrl(url)
@@ -106,6 +125,10 @@ var InstallGithubCommand = cli.Command{
 		if appId == "" {
 			return errors.New("invalid application id")
 		}
+		baseAppId := appId
+		channel = utils.CleanId(channel)
+		appId = core.ConstructChannelAppId(baseAppId, channel)
+		utils.LogDebug(fmt.Sprintf("channel id: %s", appId))
 
 		source := &core.GithubSource{
 			UserName: ghUsername,
@@ -113,13 +136,24 @@ var InstallGithubCommand = cli.Command{
 			Release:  core.GithubSourceRelease(releaseType),
 			TagName:  tagName,
 		}
+		if signatureKey != "" {
+			source.Signature, err = core.ReadSourceSignature(signatureKey)
+			if err != nil {
+				return err
+			}
+		}
 		release, err := source.FetchAptRelease()
 		if err != nil {
 			return err
 		}
 		utils.LogDebug(fmt.Sprintf("selected github tag name: %s", release.TagName))
 
-		matchScore, asset := release.ChooseAptAsset()
+		arch, err := core.GetTargetArch(nil)
+		if err != nil {
+			return err
+		}
+		utils.LogDebug(fmt.Sprintf("target arch: %s", arch))
+		matchScore, asset := release.ChooseAptAsset(arch)
 		if matchScore == core.AppImageAssetNoMatch {
 			return fmt.Errorf("no valid asset in github tag %s", release.TagName)
 		}
@@ -127,6 +161,11 @@ var InstallGithubCommand = cli.Command{
 			utils.LogWarning("no architecture specified in the asset name, cannot determine compatibility")
 		}
 		utils.LogDebug(fmt.Sprintf("selected asset url %s", asset.DownloadUrl))
+		verifiedAsset, err := release.ToVerifiedAsset(asset, source.Signature)
+		if err != nil {
+			return err
+		}
+		utils.LogDebug(fmt.Sprintf("asset sha256: %s", verifiedAsset.Sha256))
 
 		appPaths := core.ConstructAppPaths(config, appId, &core.ConstructAppPathsOptions{
 			Symlink: link,
@@ -148,14 +187,24 @@ var InstallGithubCommand = cli.Command{
 		utils.LogLn()
 		summary := utils.NewLogTable()
 		summary.Add(utils.LogRightArrowPrefix, "Identifier", color.CyanString(appId))
+		if channel != "" {
+			summary.Add(utils.LogRightArrowPrefix, "Channel", color.CyanString(channel))
+		}
 		summary.Add(utils.LogRightArrowPrefix, "Version", color.CyanString(release.TagName))
 		summary.Add(utils.LogRightArrowPrefix, "Filename", color.CyanString(asset.Name))
+		summary.Add(utils.LogRightArrowPrefix, "Architecture", color.CyanString(arch))
 		summary.Add(utils.LogRightArrowPrefix, "AppImage", color.CyanString(appPaths.AppImage))
 		summary.Add(utils.LogRightArrowPrefix, ".desktop file", color.CyanString(appPaths.Desktop))
 		if appPaths.Symlink != "" {
 			summary.Add(utils.LogRightArrowPrefix, "Symlink", color.CyanString(appPaths.Symlink))
 		}
 		summary.Add(utils.LogRightArrowPrefix, "Download Size", color.CyanString(prettyBytes(asset.Size)))
+		if verifiedAsset.Sha256 != "" {
+			summary.Add(utils.LogRightArrowPrefix, "SHA-256", color.CyanString(verifiedAsset.Sha256))
+		}
+		if verifiedAsset.Signature != nil {
+			summary.Add(utils.LogRightArrowPrefix, "Signature", color.CyanString(path.Base(verifiedAsset.SignatureUrls[0])))
+		}
 		summary.Print()
 		utils.LogLn()
 
@@ -171,16 +220,20 @@ var InstallGithubCommand = cli.Command{
 		}
 
 		app := &core.AppConfig{
-			Id:      appId,
-			Version: release.TagName,
-			Source:  core.GithubSourceId,
-			Paths:   *appPaths,
+			Id:        appId,
+			BaseId:    baseAppId,
+			Channel:   channel,
+			Version:   release.TagName,
+			Arch:      arch,
+			Source:    core.GithubSourceId,
+			Paths:     *appPaths,
+			Extracted: extract,
 		}
 		utils.LogLn()
 		installed, _ := InstallApps([]InstallableApp{{
 			App:    app,
 			Source: source,
-			Asset:  asset.ToAsset(),
+			Asset:  verifiedAsset,
 		}})
 		if installed != 1 {
 			return nil
@@ -27,11 +27,27 @@ var InstallHttpCommand = cli.Command{
 			Name:  "version",
 			Usage: "Application version",
 		},
+		&cli.StringFlag{
+			Name:  "channel",
+			Usage: "Installs into a separate slot, so that several channels (stable, beta) can coexist",
+		},
 		&cli.BoolFlag{
 			Name:    "link",
 			Aliases: []string{"l"},
 			Usage:   "Creates a symlink",
 		},
+		&cli.StringFlag{
+			Name:  "sha256",
+			Usage: "Expected SHA-256 digest of the AppImage",
+		},
+		&cli.StringFlag{
+			Name:  "signature-key",
+			Usage: "Public key file (OpenPGP, minisign or cosign) that must have signed the AppImage",
+		},
+		&cli.BoolFlag{
+			Name:  "extract",
+			Usage: "Launch from the extracted AppDir instead of mounting the AppImage",
+		},
 		&cli.BoolFlag{
 			Name:    "assume-yes",
 			Aliases: []string{"y"},
@@ -57,16 +73,37 @@ var InstallHttpCommand = cli.Command{
 		url := args.Get(0)
 		appId := cmd.String("id")
 		appVersion := cmd.String("version")
+		channel := cmd.String("channel")
 		link := cmd.Bool("link")
+		extract := cmd.Bool("extract")
+		signatureKey := cmd.String("signature-key")
+		sha256 := cmd.String("sha256")
 		assumeYes := cmd.Bool("assume-yes")
 		utils.LogDebug(fmt.Sprintf("argument url: %s", url))
 		utils.LogDebug(fmt.Sprintf("argument id: %s", appId))
+		utils.LogDebug(fmt.Sprintf("argument channel: %s", channel))
 		utils.LogDebug(fmt.Sprintf("argument link: %v", link))
+		utils.LogDebug(fmt.Sprintf("argument extract: %v", extract))
+		utils.LogDebug(fmt.Sprintf("argument signature-key: %s", signatureKey))
+		utils.LogDebug(fmt.Sprintf("argument sha256: %s", sha256))
 		utils.LogDebug(fmt.Sprintf("argument assume-yes: %v", assumeYes))
 
 		if url == "" {
 			return errors.New("invalid url")
 		}
+		if sha256 != "" {
+			sha256, err = core.NormalizeSha256(sha256)
+			if err != nil {
+				return err
+			}
+		}
+		source := &core.HttpSource{}
+		if signatureKey != "" {
+			source.Signature, err = core.ReadSourceSignature(signatureKey)
+			if err != nil {
+				return err
+			}
+		}
 
 		if appId == "" {
 			appId = core.ConstructAppId(path.Base(url))
@@ -86,6 +123,10 @@ var InstallHttpCommand = cli.Command{
 		if appId == "" {
 			return errors.New("invalid application id")
 		}
+		baseAppId := appId
+		channel = utils.CleanId(channel)
+		appId = core.ConstructChannelAppId(baseAppId, channel)
+		utils.LogDebug(fmt.Sprintf("channel id: %s", appId))
 
 		if appVersion == "" {
 			appVersion = "0.0.0"
@@ -116,12 +157,18 @@ var InstallHttpCommand = cli.Command{
 		utils.LogLn()
 		summary := utils.NewLogTable()
 		summary.Add(utils.LogRightArrowPrefix, "Identifier", color.CyanString(appId))
+		if channel != "" {
+			summary.Add(utils.LogRightArrowPrefix, "Channel", color.CyanString(channel))
+		}
 		summary.Add(utils.LogRightArrowPrefix, "Version", color.CyanString(appVersion))
 		summary.Add(utils.LogRightArrowPrefix, "AppImage", color.CyanString(appPaths.AppImage))
 		summary.Add(utils.LogRightArrowPrefix, ".desktop file", color.CyanString(appPaths.Desktop))
 		if appPaths.Symlink != "" {
 			summary.Add(utils.LogRightArrowPrefix, "Symlink", color.CyanString(appPaths.Symlink))
 		}
+		if sha256 != "" {
+			summary.Add(utils.LogRightArrowPrefix, "SHA-256", color.CyanString(sha256))
+		}
 		summary.Print()
 
 		if !assumeYes {
@@ -142,16 +189,21 @@ var InstallHttpCommand = cli.Command{
 		}
 
 		app := &core.AppConfig{
-			Id:      appId,
-			Version: appVersion,
-			Source:  core.HttpSourceId,
-			Paths:   *appPaths,
+			Id:        appId,
+			BaseId:    baseAppId,
+			Channel:   channel,
+			Version:   appVersion,
+			Source:    core.HttpSourceId,
+			Paths:     *appPaths,
+			Extracted: extract,
 		}
-		source := &core.HttpSource{}
 		asset := &core.Asset{
-			Source:   url,
-			Size:     assetMetadata.Size,
-			Download: core.NetworkAssetDownload(url),
+			Source:        url,
+			Size:          assetMetadata.Size,
+			Download:      core.NetworkAssetDownload(url),
+			Sha256:        sha256,
+			SignatureUrls: source.GetSignatureUrls(url),
+			Signature:     source.Signature,
 		}
 
 		utils.LogLn()
@@ -26,11 +26,23 @@ var InstallLocalCommand = cli.Command{
 			Name:  "version",
 			Usage: "Application version",
 		},
+		&cli.StringFlag{
+			Name:  "channel",
+			Usage: "Installs into a separate slot, so that several channels (stable, beta) can coexist",
+		},
 		&cli.BoolFlag{
 			Name:    "link",
 			Aliases: []string{"l"},
 			Usage:   "Creates a symlink",
 		},
+		&cli.StringFlag{
+			Name:  "sha256",
+			Usage: "Expected SHA-256 digest of the AppImage",
+		},
+		&cli.BoolFlag{
+			Name:  "extract",
+			Usage: "Launch from the extracted AppDir instead of mounting the AppImage",
+		},
 		&cli.BoolFlag{
 			Name:    "assume-yes",
 			Aliases: []string{"y"},
@@ -56,16 +68,28 @@ var InstallLocalCommand = cli.Command{
 		appImagePath := args.Get(0)
 		appId := cmd.String("id")
 		appVersion := cmd.String("version")
+		channel := cmd.String("channel")
 		link := cmd.Bool("link")
+		extract := cmd.Bool("extract")
+		sha256 := cmd.String("sha256")
 		assumeYes := cmd.Bool("assume-yes")
 		utils.LogDebug(fmt.Sprintf("argument path: %s", appImagePath))
 		utils.LogDebug(fmt.Sprintf("argument id: %s", appId))
+		utils.LogDebug(fmt.Sprintf("argument channel: %s", channel))
 		utils.LogDebug(fmt.Sprintf("argument link: %v", link))
+		utils.LogDebug(fmt.Sprintf("argument extract: %v", extract))
+		utils.LogDebug(fmt.Sprintf("argument sha256: %s", sha256))
 		utils.LogDebug(fmt.Sprintf("argument assume-yes: %v", assumeYes))
 
 		if appImagePath == "" {
 			return errors.New("invalid appimage path")
 		}
+		if sha256 != "" {
+			sha256, err = core.NormalizeSha256(sha256)
+			if err != nil {
+				return err
+			}
+		}
 		if !path.IsAbs(appImagePath) {
 			cwd, err := os.Getwd()
 			if err != nil {
@@ -100,6 +124,10 @@ var InstallLocalCommand = cli.Command{
 		if appId == "" {
 			return errors.New("invalid application id")
 		}
+		baseAppId := appId
+		channel = utils.CleanId(channel)
+		appId = core.ConstructChannelAppId(baseAppId, channel)
+		utils.LogDebug(fmt.Sprintf("channel id: %s", appId))
 
 		if appVersion == "" {
 			appVersion = "0.0.0"
@@ -130,12 +158,18 @@ var InstallLocalCommand = cli.Command{
 		utils.LogLn()
 		summary := utils.NewLogTable()
 		summary.Add(utils.LogRightArrowPrefix, "Identifier", color.CyanString(appId))
+		if channel != "" {
+			summary.Add(utils.LogRightArrowPrefix, "Channel", color.CyanString(channel))
+		}
 		summary.Add(utils.LogRightArrowPrefix, "Version", color.CyanString(appVersion))
 		summary.Add(utils.LogRightArrowPrefix, "AppImage", color.CyanString(appPaths.AppImage))
 		summary.Add(utils.LogRightArrowPrefix, ".desktop file", color.CyanString(appPaths.Desktop))
 		if appPaths.Symlink != "" {
 			summary.Add(utils.LogRightArrowPrefix, "Symlink", color.CyanString(appPaths.Symlink))
 		}
+		if sha256 != "" {
+			summary.Add(utils.LogRightArrowPrefix, "SHA-256", color.CyanString(sha256))
+		}
 		summary.Print()
 
 		if !assumeYes {
@@ -151,16 +185,20 @@ var InstallLocalCommand = cli.Command{
 		}
 
 		app := &core.AppConfig{
-			Id:      appId,
-			Version: appVersion,
-			Source:  core.LocalSourceId,
-			Paths:   *appPaths,
+			Id:        appId,
+			BaseId:    baseAppId,
+			Channel:   channel,
+			Version:   appVersion,
+			Source:    core.LocalSourceId,
+			Paths:     *appPaths,
+			Extracted: extract,
 		}
 		source := &core.LocalSource{}
 		asset := &core.Asset{
 			Source:   appImagePath,
 			Size:     appImageFileInfo.Size(),
 			Download: core.LocalAssetDownload(appImagePath),
+			Sha256:   sha256,
 		}
 
 		utils.LogLn()
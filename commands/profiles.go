@@ -0,0 +1,67 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/fatih/color"
+	"github.com/urfave/cli/v3"
+	"github.com/zyrouge/pho/core"
+	"github.com/zyrouge/pho/utils"
+)
+
+var ProfilesCommand = cli.Command{
+	Name:  "profiles",
+	Usage: "List all installation profiles",
+	Action: func(_ context.Context, cmd *cli.Command) error {
+		utils.LogDebug("reading profiles")
+		profiles, err := core.ListProfiles()
+		if err != nil {
+			return err
+		}
+		current := core.GetProfile()
+
+		utils.LogLn()
+		summary := utils.NewLogTable()
+		headingColor := color.New(color.Underline, color.Bold)
+		summary.Add(
+			headingColor.Sprint("Index"),
+			headingColor.Sprint("Profile"),
+			headingColor.Sprint("Applications"),
+			headingColor.Sprint("AppImages directory"),
+		)
+		for i, x := range profiles {
+			index := fmt.Sprintf("%d.", i+1)
+			if x == current {
+				index = fmt.Sprintf("%s %s", utils.LogRightArrowPrefix, index)
+			}
+			configPath, err := core.GetProfileConfigPath(x)
+			if err != nil {
+				return err
+			}
+			utils.LogDebug(fmt.Sprintf("reading config from %s", configPath))
+			config, err := core.ReadVersionedJsonFile[core.Config](configPath, core.ConfigSchema)
+			if err != nil {
+				summary.Add(index, color.CyanString(x), color.RedString(err.Error()), "")
+				continue
+			}
+			summary.Add(
+				index,
+				color.CyanString(x),
+				fmt.Sprint(len(config.Installed)),
+				config.AppsDir,
+			)
+		}
+		summary.Print()
+		if len(profiles) == 0 {
+			utils.LogInfo(
+				color.HiBlackString(
+					fmt.Sprintf("no profiles are initialized, use %s to create one", color.CyanString(fmt.Sprintf("%s --profile <name> init", core.AppExecutableName))),
+				),
+			)
+		}
+		utils.LogLn()
+
+		return nil
+	},
+}
@@ -0,0 +1,186 @@
+package commands
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/urfave/cli/v3"
+	"github.com/zyrouge/pho/core"
+	"github.com/zyrouge/pho/utils"
+)
+
+var RollbackCommand = cli.Command{
+	Name:  "rollback",
+	Usage: "Restore a previous version of an application",
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:  "to",
+			Usage: "Version to restore, defaults to the latest kept version",
+		},
+		&cli.BoolFlag{
+			Name:    "assume-yes",
+			Aliases: []string{"y"},
+			Usage:   "Automatically answer yes for questions",
+		},
+	},
+	Action: func(_ context.Context, cmd *cli.Command) error {
+		utils.LogDebug("reading config")
+		config, err := core.GetConfig()
+		if err != nil {
+			return err
+		}
+
+		reader := bufio.NewReader(os.Stdin)
+		args := cmd.Args()
+		if args.Len() == 0 {
+			return errors.New("no application id specified")
+		}
+		if args.Len() > 1 {
+			return errors.New("unexpected excessive arguments")
+		}
+
+		appId := args.Get(0)
+		to := cmd.String("to")
+		assumeYes := cmd.Bool("assume-yes")
+		utils.LogDebug(fmt.Sprintf("argument id: %s", appId))
+		utils.LogDebug(fmt.Sprintf("argument to: %s", to))
+		utils.LogDebug(fmt.Sprintf("argument assume-yes: %v", assumeYes))
+
+		if _, ok := config.Installed[appId]; !ok {
+			return fmt.Errorf(
+				"application with id %s is not installed",
+				color.CyanString(appId),
+			)
+		}
+
+		appConfigPath := core.GetAppConfigPath(config, appId)
+		utils.LogDebug(fmt.Sprintf("reading app config from %s", appConfigPath))
+		app, err := core.ReadAppConfig(appConfigPath)
+		if err != nil {
+			return err
+		}
+
+		utils.LogDebug(fmt.Sprintf("reading kept versions from %s", core.GetAppVersionsDir(&app.Paths)))
+		versions, err := core.ListAppVersions(&app.Paths)
+		if err != nil {
+			return err
+		}
+		if len(versions) == 0 {
+			return fmt.Errorf(
+				"no previous versions of %s are kept",
+				color.CyanString(appId),
+			)
+		}
+		version := &versions[0]
+		if to != "" {
+			version = core.FindAppVersion(versions, to)
+			if version == nil {
+				available := []string{}
+				for _, x := range versions {
+					available = append(available, x.Version)
+				}
+				return fmt.Errorf(
+					"version %s of %s is not kept, available versions are %s",
+					color.CyanString(to),
+					color.CyanString(appId),
+					strings.Join(available, ", "),
+				)
+			}
+		}
+
+		utils.LogDebug(fmt.Sprintf("reading kept app config from %s", version.Config))
+		kept, err := core.ReadAppConfig(version.Config)
+		if err != nil {
+			return err
+		}
+		sourceConfigPath := version.SourceConfig
+		if exists, _ := utils.FileExists(sourceConfigPath); !exists {
+			sourceConfigPath = app.Paths.SourceConfig
+		}
+		utils.LogDebug(fmt.Sprintf("reading app source config from %s", sourceConfigPath))
+		sourceConfig, err := core.ReadSourceConfig(app.Source, sourceConfigPath)
+		if err != nil {
+			return err
+		}
+		stat, err := os.Stat(version.AppImage)
+		if err != nil {
+			return err
+		}
+
+		utils.LogLn()
+		summary := utils.NewLogTable()
+		summary.Add(utils.LogRightArrowPrefix, "Identifier", color.CyanString(app.Id))
+		summary.Add(utils.LogRightArrowPrefix, "Current Version", color.CyanString(app.Version))
+		summary.Add(utils.LogRightArrowPrefix, "Restored Version", color.CyanString(kept.Version))
+		summary.Add(utils.LogRightArrowPrefix, "AppImage", color.CyanString(app.Paths.AppImage))
+		summary.Print()
+
+		if !assumeYes {
+			utils.LogLn()
+			proceed, err := utils.PromptYesNoInput(reader, "Do you want to proceed?")
+			if err != nil {
+				return err
+			}
+			if !proceed {
+				utils.LogWarning("aborted...")
+				return nil
+			}
+		}
+
+		// moved out of the versions directory, so that keeping the current
+		// version does not prune it
+		stagedDir := path.Join(app.Paths.Dir, "rollback.pho")
+		utils.LogDebug(fmt.Sprintf("moving %s to %s", version.Dir, stagedDir))
+		if err = os.RemoveAll(stagedDir); err != nil {
+			return err
+		}
+		if err = os.Rename(version.Dir, stagedDir); err != nil {
+			return err
+		}
+		stagedAppImage := path.Join(stagedDir, path.Base(version.AppImage))
+
+		app.Version = kept.Version
+		asset := &core.Asset{
+			Source:   stagedAppImage,
+			Size:     stat.Size(),
+			Download: core.LocalAssetDownload(stagedAppImage),
+			Sha256:   kept.Sha256,
+		}
+
+		utils.LogLn()
+		installed, _ := InstallApps([]InstallableApp{{
+			App:    app,
+			Source: sourceConfig,
+			Asset:  asset,
+		}})
+		if installed != 1 {
+			utils.LogDebug(fmt.Sprintf("moving %s back to %s", stagedDir, version.Dir))
+			if err = os.Rename(stagedDir, version.Dir); err != nil {
+				utils.LogError(err)
+			}
+			return nil
+		}
+		utils.LogDebug(fmt.Sprintf("removing %s", stagedDir))
+		if err = os.RemoveAll(stagedDir); err != nil {
+			utils.LogDebug(fmt.Sprintf("unable to remove %s: %v", stagedDir, err))
+		}
+
+		utils.LogLn()
+		utils.LogInfo(
+			fmt.Sprintf(
+				"%s Rolled back %s to %s successfully!",
+				utils.LogTickPrefix,
+				color.CyanString(app.Id),
+				color.CyanString(app.Version),
+			),
+		)
+
+		return nil
+	},
+}
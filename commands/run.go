@@ -24,6 +24,10 @@ var RunCommand = cli.Command{
 			Aliases: []string{"d"},
 			Usage:   "Run as a detached process",
 		},
+		&cli.BoolFlag{
+			Name:  "sandbox",
+			Usage: "Run inside a sandbox using the application's sandbox policy",
+		},
 	},
 	Action: func(_ context.Context, cmd *cli.Command) error {
 		utils.LogDebug("reading config")
@@ -47,9 +51,11 @@ var RunCommand = cli.Command{
 			execArgs = args.Slice()[2:]
 		}
 		detached := cmd.Bool("detached")
+		sandboxSet, sandbox := utils.CommandBoolSetAndValue(cmd, "sandbox")
 		utils.LogDebug(fmt.Sprintf("argument id: %s", appId))
 		utils.LogDebug(fmt.Sprintf("argument exec-args: %s", strings.Join(execArgs, " ")))
 		utils.LogDebug(fmt.Sprintf("argument detached: %v", detached))
+		utils.LogDebug(fmt.Sprintf("argument sandbox: %v", sandbox))
 
 		if _, ok := config.Installed[appId]; !ok {
 			return fmt.Errorf(
@@ -65,19 +71,35 @@ var RunCommand = cli.Command{
 			return err
 		}
 
-		execPath := app.Paths.AppImage
-		execDir, err := os.Getwd()
+		if sandboxSet {
+			app.Sandbox.Enabled = sandbox
+		}
+		launch, err := core.BuildAppLaunch(app, &core.BuildAppLaunchOptions{
+			Foreground: !detached,
+		})
 		if err != nil {
 			return err
 		}
+		execPath := launch.Exec
+		execArgs = append(launch.Args, execArgs...)
+		execDir := launch.Dir
+		if execDir == "" {
+			execDir, err = os.Getwd()
+			if err != nil {
+				return err
+			}
+		}
 		utils.LogDebug(fmt.Sprintf("exec path as %s", execPath))
+		utils.LogDebug(fmt.Sprintf("exec args as %s", strings.Join(execArgs, " ")))
 		utils.LogDebug(fmt.Sprintf("exec dir as %s", execDir))
+		utils.LogDebug(fmt.Sprintf("exec env as %s", strings.Join(launch.Env, " ")))
 
 		if detached {
 			detachedOptions := &utils.StartDetachedProcessOptions{
 				Dir:  execDir,
 				Exec: execPath,
 				Args: execArgs,
+				Env:  launch.Env,
 			}
 			if err = utils.StartDetachedProcess(detachedOptions); err != nil {
 				return err
@@ -86,8 +108,9 @@ var RunCommand = cli.Command{
 			return nil
 		}
 
-		proc := exec.Command(execPath)
+		proc := exec.Command(execPath, execArgs...)
 		proc.Dir = execDir
+		proc.Env = append(os.Environ(), launch.Env...)
 		proc.Stdin = os.Stdin
 		proc.Stdout = os.Stdout
 		proc.Stderr = os.Stderr
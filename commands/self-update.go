@@ -39,7 +39,10 @@ var SelfUpdateCommand = cli.Command{
 			)
 			return nil
 		}
-		arch := utils.GetSystemArch()
+		arch, err := utils.GetSystemArch()
+		if err != nil {
+			return err
+		}
 		var asset *core.GithubApiReleaseAsset
 		for i := range release.Assets {
 			x := release.Assets[i]
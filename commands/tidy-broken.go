@@ -15,7 +15,7 @@ import (
 var TidyBrokenCommand = cli.Command{
 	Name:    "tidy-broken",
 	Aliases: []string{},
-	Usage:   "Remove incomplete files",
+	Usage:   "Roll back incomplete installations",
 	Flags: []cli.Flag{
 		&cli.BoolFlag{
 			Name:    "assume-yes",
@@ -24,6 +24,14 @@ var TidyBrokenCommand = cli.Command{
 		},
 	},
 	Action: func(_ context.Context, cmd *cli.Command) error {
+		// installations in progress hold the lock, so only abandoned ones
+		// are rolled back
+		unlock, err := core.LockState()
+		if err != nil {
+			return err
+		}
+		defer unlock()
+
 		utils.LogDebug("reading transactions")
 		transactions, err := core.GetTransactions()
 		if err != nil {
@@ -37,12 +45,8 @@ var TidyBrokenCommand = cli.Command{
 		utils.LogLn()
 		utils.LogInfo("List of affected directories and files:")
 		involvedIds := []string{}
-		involvedDirs := []string{}
-		involvedFiles := []string{}
 		for k, v := range transactions.PendingInstallations {
 			involvedIds = append(involvedIds, k)
-			involvedDirs = append(involvedDirs, v.InvolvedDirs...)
-			involvedFiles = append(involvedFiles, v.InvolvedFiles...)
 			for _, x := range v.InvolvedDirs {
 				utils.LogInfo(
 					fmt.Sprintf("%s %s", color.HiBlackString("D"), color.RedString(x)),
@@ -53,9 +57,16 @@ var TidyBrokenCommand = cli.Command{
 					fmt.Sprintf("%s %s", color.HiBlackString("F"), color.RedString(x)),
 				)
 			}
+			if v.Backup != nil {
+				for x := range v.Backup.Files {
+					utils.LogInfo(
+						fmt.Sprintf("%s %s", color.HiBlackString("R"), color.GreenString(x)),
+					)
+				}
+			}
 		}
 
-		if len(involvedDirs)+len(involvedFiles) == 0 {
+		if len(involvedIds) == 0 {
 			utils.LogInfo(color.HiBlackString("no directories or files are affected"))
 			utils.LogLn()
 			utils.LogInfo(
@@ -76,27 +87,19 @@ var TidyBrokenCommand = cli.Command{
 			}
 		}
 
-		removedDirsCount := 0
-		removedFilesCount := 0
+		rolledBack := []string{}
 		utils.LogLn()
-		for _, x := range involvedDirs {
-			utils.LogDebug(fmt.Sprintf("removing %s", x))
-			if err := os.RemoveAll(x); err != nil {
-				utils.LogError(err)
-				continue
-			}
-			removedDirsCount++
-		}
-		for _, x := range involvedFiles {
-			utils.LogDebug(fmt.Sprintf("removing %s", x))
-			if err := os.Remove(x); err != nil {
+		for _, x := range involvedIds {
+			pending := transactions.PendingInstallations[x]
+			utils.LogDebug(fmt.Sprintf("rolling back %s", x))
+			if err := pending.Rollback(); err != nil {
 				utils.LogError(err)
 				continue
 			}
-			removedFilesCount++
+			rolledBack = append(rolledBack, x)
 		}
 		core.UpdateTransactions(func(transactions *core.Transactions) error {
-			for _, x := range involvedIds {
+			for _, x := range rolledBack {
 				delete(transactions.PendingInstallations, x)
 			}
 			return nil
@@ -105,10 +108,9 @@ var TidyBrokenCommand = cli.Command{
 		utils.LogLn()
 		utils.LogInfo(
 			fmt.Sprintf(
-				"%s Removed %d directories and %d files successfully!",
+				"%s Rolled back %d incomplete installations successfully!",
 				utils.LogTickPrefix,
-				removedDirsCount,
-				removedFilesCount,
+				len(rolledBack),
 			),
 		)
 
@@ -6,6 +6,7 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"path"
 	"strings"
 
 	"github.com/fatih/color"
@@ -24,6 +25,10 @@ var UninstallCommand = cli.Command{
 			Aliases: []string{"y"},
 			Usage:   "Automatically answer yes for questions",
 		},
+		&cli.BoolFlag{
+			Name:  "purge",
+			Usage: "Also remove portable home and config directories",
+		},
 	},
 	Action: func(_ context.Context, cmd *cli.Command) error {
 		config, err := core.GetConfig()
@@ -39,8 +44,10 @@ var UninstallCommand = cli.Command{
 
 		appIds := args.Slice()
 		assumeYes := cmd.Bool("assume-yes")
+		purge := cmd.Bool("purge")
 		utils.LogDebug(fmt.Sprintf("argument ids: %s", strings.Join(appIds, ", ")))
 		utils.LogDebug(fmt.Sprintf("argument assume-yes: %v", assumeYes))
+		utils.LogDebug(fmt.Sprintf("argument purge: %v", purge))
 
 		utils.LogLn()
 		failed := 0
@@ -106,7 +113,7 @@ var UninstallCommand = cli.Command{
 		utils.LogLn()
 		failed = 0
 		for _, x := range uninstallables {
-			failed += UninstallApp(&x)
+			failed += UninstallApp(&x, purge)
 		}
 		if failed > 0 {
 			utils.LogLn()
@@ -132,32 +139,86 @@ var UninstallCommand = cli.Command{
 	},
 }
 
-func UninstallApp(app *core.AppConfig) int {
+func UninstallApp(app *core.AppConfig, purge bool) int {
 	failed := 0
-	utils.LogDebug("reading config")
-	config, err := core.ReadConfig()
+	// held while the files are removed, so that an installation of the
+	// same application does not interleave
+	unlock, err := core.LockState()
 	if err != nil {
+		utils.LogError(err)
+		return failed + 1
+	}
+	defer unlock()
+	utils.LogDebug("saving config")
+	var config *core.Config
+	err = core.UpdateConfig(func(latest *core.Config) error {
+		delete(latest.Installed, app.Id)
+		config = latest
+		return nil
+	})
+	if err != nil {
+		// files of an application that is still registered must be kept
+		utils.LogError(err)
+		return failed + 1
+	}
+	utils.LogDebug("removing icons")
+	if err = core.UninstallThemeIcons(&app.Paths); err != nil {
 		utils.LogError(err)
 		failed++
 	} else {
-		delete(config.Installed, app.Id)
-		utils.LogDebug("saving config")
-		if err = core.SaveConfig(config); err != nil {
+		utils.LogDebug("refreshing icon cache")
+		if err = core.RefreshIconCache(config.IconsDir); err != nil {
+			utils.LogDebug(fmt.Sprintf("unable to refresh icon cache: %v", err))
+		}
+	}
+	utils.LogDebug("removing mime packages")
+	if err = core.UninstallMimePackages(&app.Paths); err != nil {
+		utils.LogError(err)
+		failed++
+	} else {
+		utils.LogDebug("updating mime database")
+		if err = core.UpdateMimeDatabase(config.MimeDir); err != nil {
+			utils.LogDebug(fmt.Sprintf("unable to update mime database: %v", err))
+		}
+	}
+	if app.Paths.Desktop != "" {
+		utils.LogDebug("removing mime associations")
+		if err = core.RemoveMimeAssociations(app.Paths.Desktop); err != nil {
 			utils.LogError(err)
 			failed++
 		}
 	}
 	utils.LogDebug(fmt.Sprintf("removing %s", app.Paths.Dir))
-	if err = os.RemoveAll(app.Paths.Dir); err != nil {
+	if err = core.RemoveAppDir(app.Paths.Dir, purge); err != nil {
 		utils.LogError(err)
 		failed++
 	}
-	utils.LogDebug(fmt.Sprintf("removing %s", app.Paths.Desktop))
-	if err = core.UninstallDesktopFile(app.Paths.Desktop); err != nil {
-		utils.LogError(err)
-		failed++
+	if !purge {
+		if kept, _ := core.HasPortableData(app.Paths.AppImage); kept {
+			utils.LogInfo(
+				fmt.Sprintf(
+					"%s Kept portable data of %s at %s",
+					utils.LogRightArrowPrefix,
+					color.CyanString(app.Id),
+					color.CyanString(app.Paths.Dir),
+				),
+			)
+		}
+	}
+	if app.Paths.Desktop != "" {
+		utils.LogDebug(fmt.Sprintf("removing %s", app.Paths.Desktop))
+		if err = core.UninstallDesktopFile(app.Paths.Desktop); err != nil {
+			utils.LogError(err)
+			failed++
+		}
+		utils.LogDebug("updating desktop database")
+		if err = core.UpdateDesktopDatabase(path.Dir(app.Paths.Desktop)); err != nil {
+			utils.LogDebug(fmt.Sprintf("unable to update desktop database: %v", err))
+		}
 	}
-	if app.Paths.Symlink != "" {
+	// the symlink may have been taken over by the same application of
+	// another profile
+	if app.Paths.Symlink != "" && core.IsSymlinkInto(app.Paths.Symlink, app.Paths.Dir) {
 		utils.LogDebug(fmt.Sprintf("removing %s", app.Paths.Symlink))
 		if err = os.Remove(app.Paths.Symlink); err != nil {
 			utils.LogError(err)
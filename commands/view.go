@@ -4,6 +4,8 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"os"
+	"strings"
 
 	"github.com/fatih/color"
 	"github.com/urfave/cli/v3"
@@ -47,18 +49,90 @@ var ViewCommand = cli.Command{
 			return err
 		}
 
+		var metainfo *core.AppMetainfo
+		if app.Paths.Metainfo != "" {
+			utils.LogDebug(fmt.Sprintf("reading metainfo from %s", app.Paths.Metainfo))
+			metainfo, err = core.ReadAppMetainfo(app.Paths.Metainfo)
+			if err != nil && !errors.Is(err, os.ErrNotExist) {
+				return err
+			}
+		}
+
 		utils.LogLn()
 		summary := utils.NewLogTable()
 		summary.Add(utils.LogRightArrowPrefix, "Identifier", color.CyanString(app.Id))
+		if metainfo != nil {
+			addViewSummaryRow(summary, "Name", metainfo.Name)
+			addViewSummaryRow(summary, "Summary", metainfo.Summary)
+			addViewSummaryRow(summary, "Developer", metainfo.Developer)
+			addViewSummaryRow(summary, "License", metainfo.License)
+			addViewSummaryRow(summary, "Homepage", metainfo.Homepage)
+		}
+		if app.Channel != "" {
+			summary.Add(utils.LogRightArrowPrefix, "Channel", color.CyanString(fmt.Sprintf("%s of %s", app.Channel, app.BaseId)))
+		}
 		summary.Add(utils.LogRightArrowPrefix, "Version", color.CyanString(app.Version))
 		summary.Add(utils.LogRightArrowPrefix, "Source", color.CyanString(string(app.Source)))
 		summary.Add(utils.LogRightArrowPrefix, "Directory", color.CyanString(app.Paths.Dir))
 		summary.Add(utils.LogRightArrowPrefix, "AppImage", color.CyanString(app.Paths.AppImage))
-		summary.Add(utils.LogRightArrowPrefix, "Icon", color.CyanString(app.Paths.Icon))
-		summary.Add(utils.LogRightArrowPrefix, ".desktop file", color.CyanString(app.Paths.Desktop))
+		if !app.Headless {
+			summary.Add(utils.LogRightArrowPrefix, "Icon", color.CyanString(app.Paths.Icon))
+			summary.Add(utils.LogRightArrowPrefix, ".desktop file", color.CyanString(app.Paths.Desktop))
+		}
+		if app.Paths.Symlink != "" {
+			summary.Add(utils.LogRightArrowPrefix, "Symlink", color.CyanString(app.Paths.Symlink))
+		}
+		summary.Add(utils.LogRightArrowPrefix, "Headless", color.CyanString(utils.BoolToYesNo(app.Headless)))
+		summary.Add(utils.LogRightArrowPrefix, "Portable", color.CyanString(utils.BoolToYesNo(app.Portable)))
+		summary.Add(utils.LogRightArrowPrefix, "Sandbox", color.CyanString(utils.BoolToYesNo(app.Sandbox.Enabled)))
+		summary.Add(utils.LogRightArrowPrefix, "Extracted", color.CyanString(utils.BoolToYesNo(app.Extracted)))
+		summary.Add(utils.LogRightArrowPrefix, "Signer", color.CyanString(app.SignerFingerprint))
+		if versions, err := core.ListAppVersions(&app.Paths); err == nil && len(versions) > 0 {
+			kept := []string{}
+			for _, x := range versions {
+				kept = append(kept, x.Version)
+			}
+			summary.Add(utils.LogRightArrowPrefix, "Kept Versions", color.CyanString(strings.Join(kept, ", ")))
+		}
 		summary.Print()
 		utils.LogLn()
 
+		if metainfo == nil {
+			return nil
+		}
+		if metainfo.Description != "" {
+			utils.LogInfo(metainfo.Description)
+			utils.LogLn()
+		}
+		if len(metainfo.Releases) > 0 {
+			utils.LogInfo(color.New(color.Underline, color.Bold).Sprint("Releases"))
+			releases := utils.NewLogTable()
+			for i, x := range metainfo.Releases {
+				if i == viewMaxReleases {
+					break
+				}
+				releases.Add(utils.LogRightArrowPrefix, color.CyanString(x.Version), color.HiBlackString(x.Date))
+			}
+			releases.Print()
+			if len(metainfo.Releases) > viewMaxReleases {
+				utils.LogInfo(
+					color.HiBlackString(
+						fmt.Sprintf("and %d older releases", len(metainfo.Releases)-viewMaxReleases),
+					),
+				)
+			}
+			utils.LogLn()
+		}
+
 		return nil
 	},
 }
+
+const viewMaxReleases = 5
+
+func addViewSummaryRow(summary *utils.LogTable, name string, value string) {
+	if value == "" {
+		return
+	}
+	summary.Add(utils.LogRightArrowPrefix, name, color.CyanString(value))
+}
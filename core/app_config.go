@@ -8,40 +8,88 @@ import (
 )
 
 type AppConfig struct {
-	Id      string   `json:"Id"`
-	Version string   `json:"Version"`
-	Source  SourceId `json:"Source"`
-	Paths   AppPaths `json:"Paths"`
+	Id           string   `json:"Id"`
+	Version      string   `json:"Version"`
+	Arch         string   `json:"Arch"`
+	Source       SourceId `json:"Source"`
+	Paths        AppPaths `json:"Paths"`
+	MimeDefaults []string `json:"MimeDefaults"`
+	// identifier of the upstream application, shared by all of its channels
+	BaseId  string `json:"BaseId"`
+	Channel string `json:"Channel"`
+
+	DesktopOverrides AppDesktopOverrides `json:"DesktopOverrides"`
+	Launch           AppLaunchProfile    `json:"Launch"`
+	Portable         bool                `json:"Portable"`
+	Sandbox          AppSandboxPolicy    `json:"Sandbox"`
+	// launched from the extracted AppDir instead of mounting the AppImage
+	Extracted bool `json:"Extracted"`
+	// no .desktop file, icons or mime types, reachable through the symlink
+	Headless bool `json:"Headless"`
+	// fingerprint of the key that signed the installed AppImage
+	SignerFingerprint string `json:"SignerFingerprint"`
+	Sha256            string `json:"Sha256"`
+}
+
+type AppDesktopOverrides struct {
+	Name       string            `json:"Name"`
+	Categories []string          `json:"Categories"`
+	Keywords   []string          `json:"Keywords"`
+	NoDisplay  *bool             `json:"NoDisplay"`
+	ExecArgs   []string          `json:"ExecArgs"`
+	Env        map[string]string `json:"Env"`
 }
 
 type SourceId string
 
+const AppMetainfoFileName = "metainfo.pho.json"
+const AppDesktopTemplateFileName = "template.pho.desktop"
+const AppDirName = "AppDir"
+
 type AppPaths struct {
-	Dir          string `json:"Dir"`
-	Config       string `json:"Config"`
-	SourceConfig string `json:"SourceConfig"`
-	AppImage     string `json:"AppImage"`
-	Icon         string `json:"Icon"`
-	Desktop      string `json:"Desktop"`
-	Symlink      string `json:"Symlink"`
+	Dir             string   `json:"Dir"`
+	Config          string   `json:"Config"`
+	SourceConfig    string   `json:"SourceConfig"`
+	Metainfo        string   `json:"Metainfo"`
+	AppImage        string   `json:"AppImage"`
+	AppDir          string   `json:"AppDir"`
+	Icon            string   `json:"Icon"`
+	Icons           []string `json:"Icons"`
+	MimePackages    []string `json:"MimePackages"`
+	Desktop         string   `json:"Desktop"`
+	DesktopTemplate string   `json:"DesktopTemplate"`
+	Symlink         string   `json:"Symlink"`
 }
 
 func ReadAppConfig(configPath string) (*AppConfig, error) {
-	return utils.ReadJsonFile[AppConfig](configPath)
+	return ReadVersionedJsonFile[AppConfig](configPath, AppConfigSchema)
 }
 
 func SaveAppConfig(configPath string, config *AppConfig) error {
-	return utils.WriteJsonFile[AppConfig](configPath, config)
+	return WriteVersionedJsonFile(configPath, config, AppConfigSchema)
 }
 
 func SaveSourceConfig[T any](configPath string, config T) error {
-	return utils.WriteJsonFile[T](configPath, &config)
+	return WriteVersionedJsonFile(configPath, config, SourceConfigSchema)
 }
 
 func ConstructAppId(appName string) string {
 	return utils.CleanId(appName)
 }
 
+// Never part of a cleaned id, so that the id of a channel cannot collide
+// with the id of another application or channel.
+const AppChannelSeparator = "_"
+
+// Channels are installed as separate applications, so that several channels
+// of the same upstream application can be installed side by side.
+func ConstructChannelAppId(appId string, channel string) string {
+	if channel == "" {
+		return appId
+	}
+	return ConstructAppId(appId) + AppChannelSeparator + ConstructAppId(channel)
+}
+
 type ConstructAppPathsOptions struct {
 	Symlink bool
 }
@@ -53,14 +101,41 @@ func ConstructAppPaths(config *Config, appId string, options *ConstructAppPathsO
 		symlinkPath = path.Join(config.SymlinksDir, appId)
 	}
 	return &AppPaths{
-		Dir:          appDir,
-		Config:       path.Join(appDir, "config.pho.json"),
-		SourceConfig: path.Join(appDir, "source.pho.json"),
-		AppImage:     path.Join(appDir, fmt.Sprintf("%s.AppImage", appId)),
-		Icon:         path.Join(appDir, fmt.Sprintf("%s.png", appId)),
-		Desktop:      path.Join(config.DesktopDir, fmt.Sprintf("%s.desktop", appId)),
-		Symlink:      symlinkPath,
+		Dir:             appDir,
+		Config:          path.Join(appDir, "config.pho.json"),
+		SourceConfig:    path.Join(appDir, "source.pho.json"),
+		Metainfo:        path.Join(appDir, AppMetainfoFileName),
+		AppImage:        path.Join(appDir, fmt.Sprintf("%s.AppImage", appId)),
+		AppDir:          path.Join(appDir, AppDirName),
+		Icon:            path.Join(appDir, fmt.Sprintf("%s.png", appId)),
+		Icons:           []string{},
+		MimePackages:    []string{},
+		Desktop:         path.Join(config.DesktopDir, ConstructAppDesktopFileName(appId)),
+		DesktopTemplate: path.Join(appDir, AppDesktopTemplateFileName),
+		Symlink:         symlinkPath,
+	}
+}
+
+func ConstructAppDesktopFileName(appId string) string {
+	if GetProfile() != "" {
+		return fmt.Sprintf("%s-%s.desktop", GetSharedNamePrefix(), appId)
+	}
+	return fmt.Sprintf("%s.desktop", appId)
+}
+
+func GetAppDirPath(paths *AppPaths) string {
+	if paths.AppDir == "" {
+		return path.Join(paths.Dir, AppDirName)
+	}
+	return paths.AppDir
+}
+
+// Returns the file that is executed when launching the application.
+func GetAppExecPath(app *AppConfig) string {
+	if app.Extracted {
+		return path.Join(GetAppDirPath(&app.Paths), "AppRun")
 	}
+	return app.Paths.AppImage
 }
 
 func GetAppConfigPath(config *Config, appId string) string {
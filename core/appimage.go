@@ -1,44 +1,209 @@
 package core
 
 import (
+	"bytes"
+	"debug/elf"
+	"encoding/binary"
 	"errors"
 	"fmt"
 	"io"
 	"os"
 	"os/exec"
 	"path"
-	"regexp"
 	"strings"
 
 	"github.com/zyrouge/pho/utils"
 )
 
+type AppImageType int
+
+const (
+	AppImageTypeUnknown AppImageType = iota
+	AppImageType1
+	AppImageType2
+)
+
+var elfMagic = []byte{0x7f, 'E', 'L', 'F'}
+
+// Reference: https://github.com/AppImage/AppImageSpec/blob/master/draft.md#image-format
+func DetectAppImageType(appImagePath string) (AppImageType, error) {
+	file, err := os.Open(appImagePath)
+	if err != nil {
+		return AppImageTypeUnknown, err
+	}
+	defer file.Close()
+	header := make([]byte, 11)
+	if _, err = io.ReadFull(file, header); err != nil {
+		return AppImageTypeUnknown, err
+	}
+	if !bytes.Equal(header[0:4], elfMagic) {
+		return AppImageTypeUnknown, errors.New("appimage is not an elf executable")
+	}
+	if header[8] == 'A' && header[9] == 'I' {
+		switch header[10] {
+		case 1:
+			return AppImageType1, nil
+
+		case 2:
+			return AppImageType2, nil
+		}
+	}
+	// type 1 images built before the magic bytes were introduced
+	if utils.IsIso9660(file) {
+		return AppImageType1, nil
+	}
+	return AppImageTypeUnknown, nil
+}
+
+var elfMachineArchs = map[elf.Machine]string{
+	elf.EM_X86_64:  "amd64",
+	elf.EM_386:     "386",
+	elf.EM_AARCH64: "arm64",
+	elf.EM_ARM:     "arm",
+}
+
+// Returns the architecture of the AppImage runtime, which is empty when the
+// machine is not known to pho.
+func DetectAppImageArch(appImagePath string) (string, error) {
+	file, err := elf.Open(appImagePath)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+	return elfMachineArchs[file.Machine], nil
+}
+
 type DeflatedAppImage struct {
 	AppImagePath string
+	Type         AppImageType
 	ParentDir    string
 	AppDir       string
 }
 
 func DeflateAppImage(appImagePath string, parentDir string) (*DeflatedAppImage, error) {
-	cmd := exec.Command(appImagePath, "--appimage-extract")
-	cmd.Dir = parentDir
-	if err := cmd.Run(); err != nil {
+	appImageType, err := DetectAppImageType(appImagePath)
+	if err != nil {
 		return nil, err
 	}
 	appDir := path.Join(parentDir, "squashfs-root")
+	switch appImageType {
+	case AppImageType1:
+		// most type 1 runtimes do not support --appimage-extract
+		if err = deflateType1AppImage(appImagePath, appDir); err != nil {
+			return nil, err
+		}
+
+	default:
+		foreign, err := isForeignAppImage(appImagePath)
+		if err != nil {
+			return nil, err
+		}
+		if foreign {
+			// the runtime cannot be executed, so the payload is extracted directly
+			if err = deflateWithUnsquashfs(appImagePath, appDir); err != nil {
+				return nil, err
+			}
+			break
+		}
+		cmd := exec.Command(appImagePath, "--appimage-extract")
+		cmd.Dir = parentDir
+		if err = cmd.Run(); err != nil {
+			return nil, err
+		}
+	}
 	deflated := &DeflatedAppImage{
 		AppImagePath: appImagePath,
+		Type:         appImageType,
 		ParentDir:    parentDir,
 		AppDir:       appDir,
 	}
 	return deflated, nil
 }
 
+func deflateType1AppImage(appImagePath string, appDir string) error {
+	file, err := os.Open(appImagePath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	return utils.ExtractIso9660(file, appDir)
+}
+
+func isForeignAppImage(appImagePath string) (bool, error) {
+	arch, err := DetectAppImageArch(appImagePath)
+	if err != nil || arch == "" {
+		return false, err
+	}
+	systemArch, err := utils.GetSystemArch()
+	if err != nil {
+		// an unknown host can only be told apart when an arch was requested
+		return ArchOverride != "", nil
+	}
+	return arch != systemArch, nil
+}
+
+// The payload of type 2 AppImages starts right after the ELF runtime, which
+// ends with the section header table.
+func GetAppImagePayloadOffset(appImagePath string) (int64, error) {
+	file, err := os.Open(appImagePath)
+	if err != nil {
+		return 0, err
+	}
+	defer file.Close()
+	header := make([]byte, 64)
+	if _, err = io.ReadFull(file, header); err != nil {
+		return 0, err
+	}
+	if !bytes.Equal(header[0:4], elfMagic) {
+		return 0, errors.New("appimage is not an elf executable")
+	}
+	var order binary.ByteOrder = binary.LittleEndian
+	if header[elf.EI_DATA] == byte(elf.ELFDATA2MSB) {
+		order = binary.BigEndian
+	}
+	if header[elf.EI_CLASS] == byte(elf.ELFCLASS64) {
+		shoff := int64(order.Uint64(header[0x28:]))
+		shentsize := int64(order.Uint16(header[0x3a:]))
+		shnum := int64(order.Uint16(header[0x3c:]))
+		return shoff + shentsize*shnum, nil
+	}
+	shoff := int64(order.Uint32(header[0x20:]))
+	shentsize := int64(order.Uint16(header[0x2e:]))
+	shnum := int64(order.Uint16(header[0x30:]))
+	return shoff + shentsize*shnum, nil
+}
+
+func deflateWithUnsquashfs(appImagePath string, appDir string) error {
+	if _, err := exec.LookPath("unsquashfs"); err != nil {
+		return errors.New("unsquashfs is required to extract appimages of a foreign architecture")
+	}
+	offset, err := GetAppImagePayloadOffset(appImagePath)
+	if err != nil {
+		return err
+	}
+	cmd := exec.Command(
+		"unsquashfs",
+		"-no-progress",
+		"-quiet",
+		"-force",
+		"-offset", fmt.Sprint(offset),
+		"-dest", appDir,
+		appImagePath,
+	)
+	return cmd.Run()
+}
+
 type DeflatedAppImageMetadata struct {
 	*DeflatedAppImage
-	ExecName    string
-	IconPath    string
-	DesktopPath string
+	ExecName     string
+	IconName     string
+	IconPath     string
+	ThemeIcons   []ThemeIcon
+	MimePackages []string
+	MetainfoPath string
+	DesktopPath  string
+	// command line tools ship no .desktop file or set Terminal=true
+	Headless bool
 }
 
 func (deflated *DeflatedAppImage) ExtractMetadata() (*DeflatedAppImageMetadata, error) {
@@ -46,21 +211,112 @@ func (deflated *DeflatedAppImage) ExtractMetadata() (*DeflatedAppImageMetadata,
 	if err != nil {
 		return nil, err
 	}
+	metainfoPath, err := FindMetainfoFile(deflated.AppDir, execName)
+	if err != nil {
+		return nil, err
+	}
+	if execName == "" {
+		metadata := &DeflatedAppImageMetadata{
+			DeflatedAppImage: deflated,
+			MetainfoPath:     metainfoPath,
+			Headless:         true,
+		}
+		return metadata, nil
+	}
 	desktopPath := path.Join(deflated.AppDir, fmt.Sprintf("%s.desktop", execName))
-	_, iconPath := utils.FindFileInDir(deflated.AppDir, []string{
-		".DirIcon",
+	mainGroup, err := readDesktopMainGroup(desktopPath)
+	if err != nil {
+		return nil, err
+	}
+	iconName := getDesktopIconName(mainGroup)
+	terminal, _ := mainGroup.Get("Terminal")
+	iconCandidates := []string{".DirIcon"}
+	if iconName != "" {
+		iconCandidates = append(
+			iconCandidates,
+			fmt.Sprintf("%s.svg", iconName),
+			fmt.Sprintf("%s.png", iconName),
+			fmt.Sprintf("%s.xpm", iconName),
+		)
+	}
+	iconCandidates = append(
+		iconCandidates,
 		fmt.Sprintf("%s.png", execName),
 		fmt.Sprintf("%s.jpg", execName),
-	})
+	)
+	_, iconPath := utils.FindFileInDir(deflated.AppDir, iconCandidates)
+	if iconPath != "" {
+		// .DirIcon is usually a symlink, which is followed only within the app directory
+		if iconPath, err = utils.ResolvePathInDir(deflated.AppDir, iconPath); err != nil {
+			iconPath = ""
+		}
+	}
+	themeIcons, err := FindThemeIcons(deflated.AppDir, iconName)
+	if err != nil {
+		return nil, err
+	}
+	mimePackages, err := FindMimePackages(deflated.AppDir)
+	if err != nil {
+		return nil, err
+	}
 	metadata := &DeflatedAppImageMetadata{
 		DeflatedAppImage: deflated,
 		ExecName:         execName,
+		IconName:         iconName,
 		IconPath:         iconPath,
+		ThemeIcons:       themeIcons,
+		MimePackages:     mimePackages,
+		MetainfoPath:     metainfoPath,
 		DesktopPath:      desktopPath,
+		Headless:         terminal == "true",
 	}
 	return metadata, nil
 }
 
+func readDesktopMainGroup(desktopPath string) (*DesktopEntryGroup, error) {
+	content, err := os.ReadFile(desktopPath)
+	if err != nil {
+		return nil, err
+	}
+	entry, err := ParseDesktopEntry(string(content))
+	if err != nil {
+		return nil, err
+	}
+	return entry.MainGroup()
+}
+
+func getDesktopIconName(group *DesktopEntryGroup) string {
+	icon, _ := group.Get("Icon")
+	if strings.Contains(icon, "/") {
+		return ""
+	}
+	// some entries wrongly include the extension
+	switch path.Ext(icon) {
+	case ".png", ".svg", ".xpm":
+		icon = strings.TrimSuffix(icon, path.Ext(icon))
+	}
+	return icon
+}
+
+// Stores the parsed AppStream metainfo next to the app config, an outdated
+// one is removed when the AppImage no longer ships it.
+func (metadata *DeflatedAppImageMetadata) SaveMetainfo(paths *AppPaths) error {
+	if paths.Metainfo == "" {
+		paths.Metainfo = path.Join(paths.Dir, AppMetainfoFileName)
+	}
+	if metadata.MetainfoPath == "" {
+		if err := os.Remove(paths.Metainfo); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+		return nil
+	}
+	metainfo, err := ParseMetainfoFile(metadata.MetainfoPath)
+	if err != nil {
+		return err
+	}
+	return SaveAppMetainfo(paths.Metainfo, metainfo)
+}
+
 func (deflated *DeflatedAppImage) ExtractExecName() (string, error) {
 	files, err := os.ReadDir(deflated.AppDir)
 	if err != nil {
@@ -72,88 +328,313 @@ func (deflated *DeflatedAppImage) ExtractExecName() (string, error) {
 			return strings.TrimSuffix(name, ".desktop"), nil
 		}
 	}
-	return "", errors.New("cannot find .desktop file from AppDir")
+	return "", nil
+}
+
+// Returns the path the icon is copied to, its extension matches the actual
+// format of the icon. The path is empty when the appimage has no icon.
+func (metadata *DeflatedAppImageMetadata) GetIconFilePath(paths *AppPaths) (string, error) {
+	if metadata.IconPath == "" || paths.Icon == "" {
+		return "", nil
+	}
+	format, err := DetectIconFormat(metadata.IconPath)
+	if err != nil {
+		return "", err
+	}
+	if format == IconFormatUnknown {
+		return paths.Icon, nil
+	}
+	return strings.TrimSuffix(paths.Icon, path.Ext(paths.Icon)) + "." + string(format), nil
 }
 
+// Copies the icon into the app directory, an empty icon path tells that the
+// appimage has no icon.
 func (metadata *DeflatedAppImageMetadata) CopyIconFile(paths *AppPaths) error {
-	if metadata.IconPath == "" {
+	iconPath, err := metadata.GetIconFilePath(paths)
+	if err != nil {
+		return err
+	}
+	if paths.Icon != "" && iconPath != paths.Icon {
+		if err = os.Remove(paths.Icon); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+	}
+	paths.Icon = iconPath
+	if iconPath == "" {
 		return nil
 	}
-	src, err := os.Open(metadata.IconPath)
+	return utils.CopyFile(metadata.IconPath, iconPath)
+}
+
+// Returns every icon shipped by the AppImage for the icon theme. When there
+// are none, the icon is used if its size fits into the theme.
+func (metadata *DeflatedAppImageMetadata) GetThemeIcons() ([]ThemeIcon, error) {
+	if len(metadata.ThemeIcons) > 0 || metadata.IconPath == "" {
+		return metadata.ThemeIcons, nil
+	}
+	format, err := DetectIconFormat(metadata.IconPath)
 	if err != nil {
-		return err
+		return nil, err
+	}
+	size := ""
+	if format.IsThemeable() {
+		size, err = GuessThemeIconSize(metadata.IconPath, format)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if size == "" {
+		return []ThemeIcon{}, nil
 	}
-	defer src.Close()
-	dest, err := os.Create(paths.Icon)
+	icon := ThemeIcon{
+		Size:   size,
+		Path:   metadata.IconPath,
+		Format: format,
+	}
+	return []ThemeIcon{icon}, nil
+}
+
+// Keeps a copy of the original .desktop file, so that the generated one can
+// be re-created without stacking modifications.
+func (metadata *DeflatedAppImageMetadata) InstallDesktopFile(app *AppConfig) error {
+	bytes, err := os.ReadFile(metadata.DesktopPath)
 	if err != nil {
 		return err
 	}
-	defer dest.Close()
-	_, err = io.Copy(dest, src)
-	return err
+	if app.Paths.DesktopTemplate == "" {
+		app.Paths.DesktopTemplate = path.Join(app.Paths.Dir, AppDesktopTemplateFileName)
+	}
+	if err = os.WriteFile(app.Paths.DesktopTemplate, bytes, utils.FilePermissions); err != nil {
+		return err
+	}
+	return InstallDesktopFile(app, string(bytes))
 }
 
-var desktopFileExecRegex = regexp.MustCompile(`Exec=("[^"]+"|[^\s]+)`)
-var desktopFileIconRegex = regexp.MustCompile(`Icon=[^\n]+`)
+// The template path is set when it has to be restored, so the app config
+// should be saved afterwards.
+func ReadDesktopTemplate(paths *AppPaths) (string, error) {
+	if paths.DesktopTemplate == "" {
+		if err := RestoreDesktopTemplate(paths); err != nil {
+			return "", err
+		}
+	}
+	bytes, err := os.ReadFile(paths.DesktopTemplate)
+	if err != nil {
+		return "", err
+	}
+	return string(bytes), nil
+}
 
-func (metadata *DeflatedAppImageMetadata) InstallDesktopFile(paths *AppPaths) error {
+// Installations prior to templates only have the generated file, which
+// already contains the modifications, so the original is extracted from the
+// AppImage again.
+func RestoreDesktopTemplate(paths *AppPaths) error {
+	tempDir, err := os.MkdirTemp(paths.Dir, "template-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tempDir)
+	utils.LogDebug(fmt.Sprintf("deflating %s into %s", paths.AppImage, tempDir))
+	deflated, err := DeflateAppImage(paths.AppImage, tempDir)
+	if err != nil {
+		return err
+	}
+	metadata, err := deflated.ExtractMetadata()
+	if err != nil {
+		return err
+	}
+	if metadata.DesktopPath == "" {
+		return errors.New("AppImage does not contain a .desktop file")
+	}
 	bytes, err := os.ReadFile(metadata.DesktopPath)
 	if err != nil {
 		return err
 	}
-	return InstallDesktopFile(paths, string(bytes))
+	templatePath := path.Join(paths.Dir, AppDesktopTemplateFileName)
+	if err = os.WriteFile(templatePath, bytes, utils.FilePermissions); err != nil {
+		return err
+	}
+	paths.DesktopTemplate = templatePath
+	return nil
+}
+
+func ReinstallDesktopFile(app *AppConfig) error {
+	if app.Headless {
+		return nil
+	}
+	content, err := ReadDesktopTemplate(&app.Paths)
+	if err != nil {
+		return err
+	}
+	return InstallDesktopFile(app, content)
 }
 
-func InstallDesktopFile(paths *AppPaths, content string) error {
+func InstallDesktopFile(app *AppConfig, content string) error {
 	config, err := ReadConfig()
 	if err != nil {
 		return err
 	}
-	execPath := utils.QuotedWhenSpace(paths.AppImage)
-	if !config.EnableIntegrationPrompt {
-		execPath = "env APPIMAGELAUNCHER_DISABLE=1 " + execPath
+	entry, err := ParseDesktopEntry(content)
+	if err != nil {
+		return err
 	}
-	content = replaceDesktopEntry(
-		content,
-		desktopFileExecRegex,
-		fmt.Sprintf("Exec=%s", execPath),
-	)
-	content = replaceDesktopEntry(
-		content,
-		desktopFileIconRegex,
-		fmt.Sprintf("Icon=%s", utils.QuotedWhenSpace(paths.Icon)),
-	)
-	content = strings.TrimSpace(content)
-	if err := os.WriteFile(paths.Desktop, []byte(content), os.ModePerm); err != nil {
+	paths := &app.Paths
+	overrides := &app.DesktopOverrides
+	launch, err := BuildAppLaunch(app, &BuildAppLaunchOptions{})
+	if err != nil {
 		return err
 	}
-	cmd := exec.Command("xdg-desktop-menu", "install", paths.Desktop, "--novendor")
+	execPrefix := buildDesktopExecPrefix(config, launch, overrides)
+	mainGroup, err := entry.MainGroup()
+	if err != nil {
+		return err
+	}
+	if !mainGroup.Has("Exec") {
+		// the program is replaced by the prefix below
+		mainGroup.SetExec("Exec", []string{EscapeDesktopExecArg(launch.Exec)})
+	}
+	for _, group := range entry.Groups {
+		if group != mainGroup && !strings.HasPrefix(group.Name, DesktopActionGroupPrefix) {
+			continue
+		}
+		if err = rewriteDesktopExec(group, execPrefix); err != nil {
+			return fmt.Errorf("invalid exec in [%s]: %v", group.Name, err)
+		}
+		if group.Has("TryExec") {
+			group.Set("TryExec", GetAppExecPath(app))
+		}
+	}
+	if icon := GetDesktopIconValue(paths); icon != "" {
+		mainGroup.Set("Icon", icon)
+	}
+	if launch.Dir != "" {
+		mainGroup.Set("Path", launch.Dir)
+	}
+	if app.Channel != "" {
+		applyDesktopChannel(mainGroup, app.Channel)
+	}
+	applyDesktopOverrides(mainGroup, overrides)
+	if err := os.WriteFile(paths.Desktop, []byte(entry.String()), utils.FilePermissions); err != nil {
+		return err
+	}
+	cmd := exec.Command("xdg-desktop-menu", "install", "--mode", GetXdgUtilsMode(), "--novendor", paths.Desktop)
 	return cmd.Run()
 }
 
+func buildDesktopExecPrefix(config *Config, launch *AppLaunch, overrides *AppDesktopOverrides) []string {
+	env := []string{}
+	if !config.EnableIntegrationPrompt {
+		env = append(env, "APPIMAGELAUNCHER_DISABLE=1")
+	}
+	env = append(env, launch.Env...)
+	env = append(env, utils.FormatEnvMap(overrides.Env)...)
+	execPrefix := []string{}
+	if len(env) > 0 {
+		execPrefix = append(execPrefix, "env")
+		execPrefix = append(execPrefix, env...)
+	}
+	execPrefix = append(execPrefix, launch.Exec)
+	execPrefix = append(execPrefix, launch.Args...)
+	execPrefix = append(execPrefix, overrides.ExecArgs...)
+	for i, x := range execPrefix {
+		execPrefix[i] = EscapeDesktopExecArg(x)
+	}
+	return execPrefix
+}
+
+// Tells apart the entries of the channels of an application, localized names
+// are dropped so that the suffix is always shown.
+func applyDesktopChannel(group *DesktopEntryGroup, channel string) {
+	name, _ := group.Get("Name")
+	group.Delete("Name")
+	group.Set("Name", fmt.Sprintf("%s (%s)", name, channel))
+}
+
+// Localized variants are dropped, otherwise they would take precedence over
+// the overridden value.
+func applyDesktopOverrides(group *DesktopEntryGroup, overrides *AppDesktopOverrides) {
+	if overrides.Name != "" {
+		group.Delete("Name")
+		group.Set("Name", overrides.Name)
+	}
+	if len(overrides.Categories) > 0 {
+		group.SetList("Categories", overrides.Categories)
+	}
+	if len(overrides.Keywords) > 0 {
+		group.Delete("Keywords")
+		group.SetList("Keywords", overrides.Keywords)
+	}
+	if overrides.NoDisplay != nil {
+		group.SetBool("NoDisplay", *overrides.NoDisplay)
+	}
+}
+
+// Replaces only the executable, keeping the environment variables set
+// through `env`, arguments and field codes intact.
+func rewriteDesktopExec(group *DesktopEntryGroup, execPrefix []string) error {
+	args, ok, err := group.GetExec("Exec")
+	if err != nil || !ok {
+		return err
+	}
+	programIndex := DesktopExecProgramIndex(args)
+	rest := []string{}
+	if programIndex < len(args) {
+		rest = args[programIndex+1:]
+	}
+	command := []string{}
+	if programIndex > 0 {
+		// variables of pho come later, so that they take precedence
+		command = append(command, args[:programIndex]...)
+		if len(execPrefix) > 0 && execPrefix[0] == "env" {
+			execPrefix = execPrefix[1:]
+		}
+	}
+	command = append(command, execPrefix...)
+	group.SetExec("Exec", append(command, rest...))
+	return nil
+}
+
 func UninstallDesktopFile(desktopFilePath string) error {
-	cmd := exec.Command("xdg-desktop-menu", "uninstall", desktopFilePath, "--novendor")
+	cmd := exec.Command("xdg-desktop-menu", "uninstall", "--mode", GetXdgUtilsMode(), "--novendor", desktopFilePath)
 	return cmd.Run()
 }
 
-func (metadata *DeflatedAppImageMetadata) Symlink(paths *AppPaths) error {
-	if err := os.Symlink(paths.AppImage, paths.Symlink); err != nil {
+// Reports whether name is a symlink that points into dir.
+func IsSymlinkInto(name string, dir string) bool {
+	target, err := os.Readlink(name)
+	if err != nil {
+		return false
+	}
+	if !path.IsAbs(target) {
+		target = path.Join(path.Dir(name), target)
+	}
+	return strings.HasPrefix(path.Clean(target), path.Clean(dir)+"/")
+}
+
+func (metadata *DeflatedAppImageMetadata) Symlink(app *AppConfig) error {
+	if err := os.MkdirAll(path.Dir(app.Paths.Symlink), utils.DirPermissions); err != nil {
+		return err
+	}
+	if _, err := os.Lstat(app.Paths.Symlink); err == nil {
+		if !IsSymlinkInto(app.Paths.Symlink, app.Paths.Dir) {
+			return fmt.Errorf("%s already exists and does not belong to %s", app.Paths.Symlink, app.Id)
+		}
+		if err = os.Remove(app.Paths.Symlink); err != nil {
+			return err
+		}
+	}
+	if err := os.Symlink(GetAppExecPath(app), app.Paths.Symlink); err != nil {
 		return err
 	}
 	return nil
 }
 
-func replaceDesktopEntry(content string, pattern *regexp.Regexp, replaceWith string) string {
-	count := 0
-	content = pattern.ReplaceAllStringFunc(content, func(s string) string {
-		count++
-		return replaceWith
-	})
-	if count == 0 {
-		if !strings.HasSuffix(content, "\n") {
-			content += "\n"
-		}
-		content += replaceWith
+// Moves the extracted AppDir into the application directory, replacing the
+// previous one.
+func (metadata *DeflatedAppImageMetadata) KeepAppDir(paths *AppPaths) error {
+	paths.AppDir = GetAppDirPath(paths)
+	if err := os.RemoveAll(paths.AppDir); err != nil {
+		return err
 	}
-	return content
+	return os.Rename(metadata.AppDir, paths.AppDir)
 }
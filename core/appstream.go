@@ -0,0 +1,255 @@
+package core
+
+import (
+	"encoding/xml"
+	"errors"
+	"io"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/zyrouge/pho/utils"
+)
+
+// Reference: https://www.freedesktop.org/software/appstream/docs/chap-Metadata.html
+
+var metainfoDirs = []string{
+	"usr/share/metainfo",
+	"usr/share/appdata",
+}
+
+var metainfoSuffixes = []string{
+	".metainfo.xml",
+	".appdata.xml",
+}
+
+type AppMetainfo struct {
+	Id          string               `json:"Id"`
+	Name        string               `json:"Name"`
+	Summary     string               `json:"Summary"`
+	Description string               `json:"Description"`
+	License     string               `json:"License"`
+	Homepage    string               `json:"Homepage"`
+	Developer   string               `json:"Developer"`
+	Releases    []AppMetainfoRelease `json:"Releases"`
+}
+
+type AppMetainfoRelease struct {
+	Version     string `json:"Version"`
+	Date        string `json:"Date"`
+	Description string `json:"Description"`
+}
+
+type appstreamComponent struct {
+	Id             string                 `xml:"id"`
+	Names          []appstreamText        `xml:"name"`
+	Summaries      []appstreamText        `xml:"summary"`
+	Descriptions   []appstreamDescription `xml:"description"`
+	ProjectLicense string                 `xml:"project_license"`
+	Urls           []appstreamUrl         `xml:"url"`
+	DeveloperNames []appstreamText        `xml:"developer_name"`
+	Developer      struct {
+		Names []appstreamText `xml:"name"`
+	} `xml:"developer"`
+	Releases struct {
+		Releases []appstreamRelease `xml:"release"`
+	} `xml:"releases"`
+}
+
+type appstreamText struct {
+	Lang  string `xml:"lang,attr"`
+	Value string `xml:",chardata"`
+}
+
+type appstreamDescription struct {
+	Lang  string `xml:"lang,attr"`
+	Inner string `xml:",innerxml"`
+}
+
+type appstreamUrl struct {
+	Type  string `xml:"type,attr"`
+	Value string `xml:",chardata"`
+}
+
+type appstreamRelease struct {
+	Version      string                 `xml:"version,attr"`
+	Date         string                 `xml:"date,attr"`
+	Timestamp    string                 `xml:"timestamp,attr"`
+	Descriptions []appstreamDescription `xml:"description"`
+}
+
+func ReadAppMetainfo(name string) (*AppMetainfo, error) {
+	return utils.ReadJsonFile[AppMetainfo](name)
+}
+
+func SaveAppMetainfo(name string, metainfo *AppMetainfo) error {
+	return utils.WriteJsonFile[AppMetainfo](name, metainfo)
+}
+
+// Finds the AppStream metainfo file inside the AppDir, preferring the one
+// named after the executable. Returns an empty string when there is none.
+func FindMetainfoFile(appDir string, execName string) (string, error) {
+	found := ""
+	for _, dir := range metainfoDirs {
+		files, err := os.ReadDir(path.Join(appDir, dir))
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return "", err
+		}
+		for _, x := range files {
+			name := x.Name()
+			for _, suffix := range metainfoSuffixes {
+				if !strings.HasSuffix(name, suffix) {
+					continue
+				}
+				current := path.Join(appDir, dir, name)
+				if strings.TrimSuffix(name, suffix) == execName {
+					return current, nil
+				}
+				if found == "" {
+					found = current
+				}
+			}
+		}
+	}
+	return found, nil
+}
+
+func ParseMetainfoFile(name string) (*AppMetainfo, error) {
+	file, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return ParseMetainfo(file)
+}
+
+func ParseMetainfo(reader io.Reader) (*AppMetainfo, error) {
+	component := &appstreamComponent{}
+	decoder := xml.NewDecoder(reader)
+	if err := decoder.Decode(component); err != nil {
+		return nil, err
+	}
+	metainfo := &AppMetainfo{
+		Id:          strings.TrimSpace(component.Id),
+		Name:        unlocalizedAppstreamText(component.Names),
+		Summary:     unlocalizedAppstreamText(component.Summaries),
+		Description: unlocalizedAppstreamDescription(component.Descriptions),
+		License:     strings.TrimSpace(component.ProjectLicense),
+		Developer:   unlocalizedAppstreamText(component.Developer.Names),
+		Releases:    []AppMetainfoRelease{},
+	}
+	if metainfo.Developer == "" {
+		metainfo.Developer = unlocalizedAppstreamText(component.DeveloperNames)
+	}
+	for _, x := range component.Urls {
+		if x.Type == "homepage" {
+			metainfo.Homepage = strings.TrimSpace(x.Value)
+			break
+		}
+	}
+	for _, x := range component.Releases.Releases {
+		date := x.Date
+		if timestamp, err := strconv.ParseInt(x.Timestamp, 10, 64); date == "" && err == nil {
+			date = time.Unix(timestamp, 0).UTC().Format(time.DateOnly)
+		}
+		metainfo.Releases = append(metainfo.Releases, AppMetainfoRelease{
+			Version:     x.Version,
+			Date:        date,
+			Description: unlocalizedAppstreamDescription(x.Descriptions),
+		})
+	}
+	return metainfo, nil
+}
+
+func unlocalizedAppstreamText(texts []appstreamText) string {
+	for _, x := range texts {
+		if x.Lang == "" {
+			return strings.TrimSpace(x.Value)
+		}
+	}
+	return ""
+}
+
+func unlocalizedAppstreamDescription(descriptions []appstreamDescription) string {
+	for _, x := range descriptions {
+		if x.Lang == "" {
+			return formatAppstreamDescription(x.Inner)
+		}
+	}
+	return ""
+}
+
+// Converts the markup of a description into plain text, paragraphs are
+// separated by blank lines and list items are prefixed with a dash.
+func formatAppstreamDescription(markup string) string {
+	decoder := xml.NewDecoder(strings.NewReader(markup))
+	blocks := []string{}
+	var current strings.Builder
+	localizedDepth := 0
+	flush := func(prefix string) {
+		text := strings.Join(strings.Fields(current.String()), " ")
+		if text != "" {
+			blocks = append(blocks, prefix+text)
+		}
+		current.Reset()
+	}
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			break
+		}
+		switch x := token.(type) {
+		case xml.StartElement:
+			// old style translations are inlined as sibling elements
+			if localizedDepth > 0 || hasXmlLang(x) {
+				localizedDepth++
+			}
+
+		case xml.EndElement:
+			if localizedDepth > 0 {
+				localizedDepth--
+				continue
+			}
+			switch x.Name.Local {
+			case "p":
+				flush("")
+
+			case "li":
+				flush("- ")
+			}
+
+		case xml.CharData:
+			if localizedDepth == 0 {
+				current.Write(x)
+			}
+		}
+	}
+	flush("")
+	var text strings.Builder
+	for i, x := range blocks {
+		if i > 0 {
+			isListItem := strings.HasPrefix(x, "- ") && strings.HasPrefix(blocks[i-1], "- ")
+			if isListItem {
+				text.WriteString("\n")
+			} else {
+				text.WriteString("\n\n")
+			}
+		}
+		text.WriteString(x)
+	}
+	return text.String()
+}
+
+func hasXmlLang(element xml.StartElement) bool {
+	for _, x := range element.Attr {
+		if x.Name.Local == "lang" {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,211 @@
+package core
+
+import (
+	"os"
+	"path"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseMetainfo(t *testing.T) {
+	tests := []struct {
+		name string
+		xml  string
+		want AppMetainfo
+	}{
+		{
+			name: "complete component",
+			xml: `<?xml version="1.0" encoding="UTF-8"?>
+<component type="desktop-application">
+  <id> org.example.Foo </id>
+  <name>Foo</name>
+  <name xml:lang="de">Fuu</name>
+  <summary xml:lang="de">Macht foo</summary>
+  <summary>Does foo</summary>
+  <project_license>MIT</project_license>
+  <developer_name>Foo Team</developer_name>
+  <url type="bugtracker">https://foo.org/issues</url>
+  <url type="homepage"> https://foo.org </url>
+  <description><p>Foo does things.</p></description>
+  <releases>
+    <release version="1.2" date="2024-01-02"><description><p>Fixes</p></description></release>
+    <release version="1.1" timestamp="1700000000"/>
+  </releases>
+</component>`,
+			want: AppMetainfo{
+				Id:          "org.example.Foo",
+				Name:        "Foo",
+				Summary:     "Does foo",
+				Description: "Foo does things.",
+				License:     "MIT",
+				Homepage:    "https://foo.org",
+				Developer:   "Foo Team",
+				Releases: []AppMetainfoRelease{
+					{Version: "1.2", Date: "2024-01-02", Description: "Fixes"},
+					{Version: "1.1", Date: "2023-11-14"},
+				},
+			},
+		},
+		{
+			name: "developer element takes precedence",
+			xml: `<component>
+  <id>foo</id>
+  <developer id="org.example"><name xml:lang="de">Fuu Team</name><name>Foo Team</name></developer>
+  <developer_name>Legacy Team</developer_name>
+</component>`,
+			want: AppMetainfo{
+				Id:        "foo",
+				Developer: "Foo Team",
+				Releases:  []AppMetainfoRelease{},
+			},
+		},
+		{
+			name: "localized description blocks",
+			xml: `<component>
+  <description xml:lang="de"><p>Erste</p></description>
+  <description><p>First</p></description>
+</component>`,
+			want: AppMetainfo{
+				Description: "First",
+				Releases:    []AppMetainfoRelease{},
+			},
+		},
+		{
+			name: "only localized texts",
+			xml:  `<component><name xml:lang="de">Fuu</name></component>`,
+			want: AppMetainfo{
+				Releases: []AppMetainfoRelease{},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseMetainfo(strings.NewReader(tt.xml))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(*got, tt.want) {
+				t.Errorf("got %+v, want %+v", *got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseMetainfoErrors(t *testing.T) {
+	for _, x := range []string{"", "<component>", "not xml"} {
+		if _, err := ParseMetainfo(strings.NewReader(x)); err == nil {
+			t.Errorf("%q: expected an error", x)
+		}
+	}
+}
+
+func TestFormatAppstreamDescription(t *testing.T) {
+	tests := []struct {
+		name   string
+		markup string
+		want   string
+	}{
+		{
+			name:   "paragraphs",
+			markup: "<p>First   para.</p>\n  <p>Second\n  para.</p>",
+			want:   "First para.\n\nSecond para.",
+		},
+		{
+			name:   "lists",
+			markup: "<p>Features:</p><ul><li>One</li><li>Two</li></ul><ol><li>Three</li></ol><p>Last</p>",
+			want:   "Features:\n\n- One\n- Two\n- Three\n\nLast",
+		},
+		{
+			name:   "inline markup",
+			markup: "<p>Use <code>foo --bar</code> for <em>fast</em> results.</p>",
+			want:   "Use foo --bar for fast results.",
+		},
+		{
+			name:   "entities",
+			markup: "<p>Fish &amp; chips &lt;3</p>",
+			want:   "Fish & chips <3",
+		},
+		{
+			name:   "inline localized paragraphs",
+			markup: `<p>First</p><p xml:lang="de">Erste</p><ul><li>One</li><li xml:lang="de">Eins</li></ul>`,
+			want:   "First\n\n- One",
+		},
+		{
+			name:   "nested markup in localized paragraphs",
+			markup: `<p xml:lang="de">Mit <em>Betonung</em></p><p>With <em>emphasis</em></p>`,
+			want:   "With emphasis",
+		},
+		{
+			name:   "empty",
+			markup: "",
+			want:   "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := formatAppstreamDescription(tt.markup); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFindMetainfoFile(t *testing.T) {
+	tests := []struct {
+		name     string
+		files    []string
+		execName string
+		want     string
+	}{
+		{
+			name:     "none",
+			files:    []string{},
+			execName: "foo",
+			want:     "",
+		},
+		{
+			name:     "named after the executable",
+			files:    []string{"usr/share/metainfo/bar.metainfo.xml", "usr/share/metainfo/foo.metainfo.xml"},
+			execName: "foo",
+			want:     "usr/share/metainfo/foo.metainfo.xml",
+		},
+		{
+			name:     "legacy appdata directory",
+			files:    []string{"usr/share/metainfo/bar.metainfo.xml", "usr/share/appdata/foo.appdata.xml"},
+			execName: "foo",
+			want:     "usr/share/appdata/foo.appdata.xml",
+		},
+		{
+			name:     "first one as fallback",
+			files:    []string{"usr/share/metainfo/bar.metainfo.xml", "usr/share/metainfo/readme.txt"},
+			execName: "foo",
+			want:     "usr/share/metainfo/bar.metainfo.xml",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			appDir := t.TempDir()
+			for _, x := range tt.files {
+				name := path.Join(appDir, x)
+				if err := os.MkdirAll(path.Dir(name), 0755); err != nil {
+					t.Fatal(err)
+				}
+				if err := os.WriteFile(name, []byte("<component/>"), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			got, err := FindMetainfoFile(appDir, tt.execName)
+			if err != nil {
+				t.Fatal(err)
+			}
+			want := ""
+			if tt.want != "" {
+				want = path.Join(appDir, tt.want)
+			}
+			if got != want {
+				t.Errorf("got %q, want %q", got, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,49 @@
+package core
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/zyrouge/pho/utils"
+)
+
+// Set by the global `--arch` flag.
+var ArchOverride string
+
+func ParseArch(value string) (string, error) {
+	arch := utils.FindArch(value)
+	if arch == "" {
+		supported := []string{}
+		for x := range utils.ArchMap {
+			supported = append(supported, x)
+		}
+		sort.Strings(supported)
+		return "", fmt.Errorf(
+			"unsupported architecture %s, use one of %s",
+			value,
+			strings.Join(supported, ", "),
+		)
+	}
+	return arch, nil
+}
+
+// Returns the architecture AppImages are installed for, which is the
+// `--arch` flag, the arch the application was installed for, the configured
+// arch or the system arch in that order.
+func GetTargetArch(app *AppConfig) (string, error) {
+	if ArchOverride != "" {
+		return ParseArch(ArchOverride)
+	}
+	if app != nil && app.Arch != "" {
+		return app.Arch, nil
+	}
+	config, err := GetConfig()
+	if err != nil {
+		return "", err
+	}
+	if config.Arch != "" {
+		return ParseArch(config.Arch)
+	}
+	return utils.GetSystemArch()
+}
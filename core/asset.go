@@ -15,6 +15,23 @@ type Asset struct {
 	Source   string
 	Size     int64
 	Download AssetDownloadFunc
+	// expected digest, verification is skipped when empty
+	Sha256 string
+	// candidate urls of the detached signature, checked when a key is trusted
+	SignatureUrls []string
+	Signature     *SourceSignature
+}
+
+func (asset *Asset) VerifySignature(name string) error {
+	scheme, err := asset.Signature.Scheme()
+	if err != nil {
+		return err
+	}
+	data, err := FetchDetachedSignature(asset.SignatureUrls, scheme)
+	if err != nil {
+		return err
+	}
+	return asset.Signature.Verify(name, data)
 }
 
 func NetworkAssetDownload(url string) AssetDownloadFunc {
@@ -61,8 +78,7 @@ const (
 	AppImageAssetExactMatch
 )
 
-func ChooseAptAppImageAsset[T any](assets []T, assetNameFunc func(*T) string) (AppImageAssetMatch, *T) {
-	arch := utils.GetSystemArch()
+func ChooseAptAppImageAsset[T any](assets []T, arch string, assetNameFunc func(*T) string) (AppImageAssetMatch, *T) {
 	var fallback *T
 	for i := range assets {
 		asset := &assets[i]
@@ -85,13 +101,17 @@ func ChooseAptAppImageAsset[T any](assets []T, assetNameFunc func(*T) string) (A
 	return AppImageAssetNoMatch, nil
 }
 
+// The longest alias wins, so that "arm64" is not matched as "arm".
 func extractArch(name string) string {
+	matchedArch := ""
+	matchedAlias := ""
 	for arch, aliases := range utils.ArchMap {
 		for _, x := range aliases {
-			if strings.Contains(name, x) {
-				return arch
+			if len(x) > len(matchedAlias) && strings.Contains(name, x) {
+				matchedArch = arch
+				matchedAlias = x
 			}
 		}
 	}
-	return ""
+	return matchedArch
 }
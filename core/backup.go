@@ -0,0 +1,135 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path"
+
+	"github.com/zyrouge/pho/utils"
+)
+
+const AppBackupDirName = "backup.pho"
+
+// Snapshot of the files of an installed application, taken before it is
+// modified so that a failed installation can be rolled back.
+type AppBackup struct {
+	Dir string `json:"Dir"`
+	// original path to backed up path
+	Files map[string]string `json:"Files"`
+	// files that did not exist, removed on restore
+	Missing []string `json:"Missing"`
+}
+
+// Files of an application that live outside of its directory.
+func GetAppExternalFiles(paths *AppPaths) []string {
+	files := []string{}
+	for _, x := range []string{paths.Desktop, paths.Symlink} {
+		if x != "" {
+			files = append(files, x)
+		}
+	}
+	files = append(files, paths.Icons...)
+	files = append(files, paths.MimePackages...)
+	return files
+}
+
+func BackupApp(paths *AppPaths) (*AppBackup, error) {
+	backup := &AppBackup{
+		Dir:     path.Join(paths.Dir, AppBackupDirName),
+		Files:   map[string]string{},
+		Missing: []string{},
+	}
+	if err := os.RemoveAll(backup.Dir); err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(backup.Dir, utils.DirPermissions); err != nil {
+		return nil, err
+	}
+	names := []string{
+		paths.Config,
+		paths.SourceConfig,
+		paths.Metainfo,
+		paths.AppImage,
+		GetAppDirPath(paths),
+		paths.Icon,
+		paths.DesktopTemplate,
+	}
+	names = append(names, GetAppExternalFiles(paths)...)
+	for i, x := range names {
+		if x == "" {
+			continue
+		}
+		if _, ok := backup.Files[x]; ok {
+			continue
+		}
+		dest := path.Join(backup.Dir, fmt.Sprintf("%d-%s", i, path.Base(x)))
+		err := backupPath(x, dest)
+		if errors.Is(err, os.ErrNotExist) {
+			backup.Missing = append(backup.Missing, x)
+			continue
+		}
+		if err != nil {
+			backup.Discard()
+			return nil, err
+		}
+		backup.Files[x] = dest
+	}
+	return backup, nil
+}
+
+// Regular files are copied as they get overwritten in place, while the
+// AppImage is hard linked since it is always replaced by a rename.
+func backupPath(src string, dest string) error {
+	info, err := os.Lstat(src)
+	if err != nil {
+		return err
+	}
+	switch {
+	case info.IsDir():
+		return utils.CopyDir(src, dest)
+
+	case info.Mode()&os.ModeSymlink != 0:
+		link, err := os.Readlink(src)
+		if err != nil {
+			return err
+		}
+		return os.Symlink(link, dest)
+	}
+	if path.Ext(src) == ".AppImage" {
+		if err = os.Link(src, dest); err == nil {
+			return nil
+		}
+	}
+	if err = utils.CopyFile(src, dest); err != nil {
+		return err
+	}
+	return os.Chmod(dest, info.Mode().Perm())
+}
+
+func (backup *AppBackup) Restore() error {
+	for _, x := range backup.Missing {
+		if err := os.RemoveAll(x); err != nil {
+			return err
+		}
+	}
+	for original, backed := range backup.Files {
+		if err := os.RemoveAll(original); err != nil {
+			return err
+		}
+		if err := os.MkdirAll(path.Dir(original), utils.DirPermissions); err != nil {
+			return err
+		}
+		if err := os.Rename(backed, original); err != nil {
+			// the backup directory can be on another device
+			if err = backupPath(backed, original); err != nil {
+				return err
+			}
+		}
+	}
+	return backup.Discard()
+}
+
+func (backup *AppBackup) Discard() error {
+	return os.RemoveAll(backup.Dir)
+}
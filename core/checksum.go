@@ -0,0 +1,61 @@
+package core
+
+import (
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+const Sha256DigestPrefix = "sha256:"
+
+func NormalizeSha256(value string) (string, error) {
+	value = strings.ToLower(strings.TrimPrefix(strings.TrimSpace(value), Sha256DigestPrefix))
+	if decoded, err := hex.DecodeString(value); err != nil || len(decoded) != 32 {
+		return "", fmt.Errorf("invalid sha256 digest %s", value)
+	}
+	return value, nil
+}
+
+// Parses the output of `sha256sum`, a file containing only the digest is
+// treated as the digest of the given name.
+func ParseSha256Sums(content string, name string) string {
+	lines := strings.Split(strings.TrimSpace(content), "\n")
+	for _, x := range lines {
+		fields := strings.Fields(x)
+		if len(fields) == 1 && len(lines) == 1 {
+			if digest, err := NormalizeSha256(fields[0]); err == nil {
+				return digest
+			}
+		}
+		if len(fields) != 2 {
+			continue
+		}
+		// binary mode entries are prefixed with an asterisk
+		fileName := strings.TrimPrefix(fields[1], "*")
+		if fileName != name && !strings.HasSuffix(fileName, "/"+name) {
+			continue
+		}
+		if digest, err := NormalizeSha256(fields[0]); err == nil {
+			return digest
+		}
+	}
+	return ""
+}
+
+func FetchSha256Sums(url string, name string) (string, error) {
+	res, err := http.Get(url)
+	if err != nil {
+		return "", err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != 200 {
+		return "", fmt.Errorf("checksum file %s returned status %d", url, res.StatusCode)
+	}
+	content, err := io.ReadAll(io.LimitReader(res.Body, 1<<20))
+	if err != nil {
+		return "", err
+	}
+	return ParseSha256Sums(string(content), name), nil
+}
@@ -16,31 +16,79 @@ type Config struct {
 	Installed               map[string]string `json:"Installed"`
 	EnableIntegrationPrompt bool              `json:"EnableIntegrationPrompt"`
 	SymlinksDir             string            `json:"SymlinksDir"`
+	IconsDir                string            `json:"IconsDir"`
+	MimeDir                 string            `json:"MimeDir"`
+	SignaturePolicy         SignaturePolicy   `json:"SignaturePolicy"`
+	// installs AppImages for this architecture instead of the system one
+	Arch string `json:"Arch"`
+	// previous versions of each application kept for rollbacks
+	KeepVersions int `json:"KeepVersions"`
 }
 
 var cachedConfig *Config
 
-func GetConfigPath() (string, error) {
+const ConfigDirEnv = "PHO_CONFIG_DIR"
+
+// config file passed with --config
+var ConfigPathOverride = ""
+
+func GetConfigDir() (string, error) {
+	if ConfigPathOverride != "" {
+		return path.Dir(ConfigPathOverride), nil
+	}
+	if SystemMode {
+		return SystemConfigDir, nil
+	}
+	if dir := os.Getenv(ConfigDirEnv); dir != "" {
+		return utils.ResolvePath(dir)
+	}
 	xdgConfigDir, err := os.UserConfigDir()
 	if err != nil {
 		return "", err
 	}
-	configPath := path.Join(xdgConfigDir, AppCodeName, "config.json")
+	return path.Join(xdgConfigDir, AppCodeName), nil
+}
+
+func GetConfigPath() (string, error) {
+	if profile := GetProfile(); profile != "" {
+		return GetProfileConfigPath(profile)
+	}
+	return getBaseConfigPath()
+}
+
+// Config path without the profile.
+func getBaseConfigPath() (string, error) {
+	if ConfigPathOverride != "" {
+		return ConfigPathOverride, nil
+	}
+	configDir, err := GetConfigDir()
+	if err != nil {
+		return "", err
+	}
+	configPath := path.Join(configDir, "config.json")
 	return configPath, nil
 }
 
+func IsDefaultConfigPath() bool {
+	return ConfigPathOverride == "" && !SystemMode && os.Getenv(ConfigDirEnv) == ""
+}
+
 func ReadConfig() (*Config, error) {
 	cachedConfig = nil
 	configPath, err := GetConfigPath()
 	if err != nil {
 		return nil, err
 	}
-	config, err := utils.ReadJsonFile[Config](configPath)
+	config, err := ReadVersionedJsonFile[Config](configPath, ConfigSchema)
 	if errors.Is(err, os.ErrNotExist) {
+		initCommand := "init"
+		if profile := GetProfile(); profile != "" {
+			initCommand = fmt.Sprintf("--profile %s init", profile)
+		}
 		return nil, fmt.Errorf(
 			"config file does not exist, use %s %s to initiate the setup",
 			color.CyanString(AppExecutableName),
-			color.CyanString("init"),
+			color.CyanString(initCommand),
 		)
 	}
 	if err != nil {
@@ -55,7 +103,7 @@ func SaveConfig(config *Config) error {
 	if err != nil {
 		return err
 	}
-	err = utils.WriteJsonFileAtomic[Config](configPath, config)
+	err = WriteVersionedJsonFile(configPath, config, ConfigSchema)
 	if err != nil {
 		return err
 	}
@@ -63,6 +111,26 @@ func SaveConfig(config *Config) error {
 	return nil
 }
 
+type UpdateConfigFunc func(config *Config) error
+
+// Re-reads the config while holding the state lock, so that concurrent
+// processes do not overwrite each other's changes.
+func UpdateConfig(performer UpdateConfigFunc) error {
+	unlock, err := LockState()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	config, err := ReadConfig()
+	if err != nil {
+		return err
+	}
+	if err = performer(config); err != nil {
+		return err
+	}
+	return SaveConfig(config)
+}
+
 func GetConfig() (*Config, error) {
 	if cachedConfig == nil {
 		return ReadConfig()
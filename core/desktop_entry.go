@@ -0,0 +1,457 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Reference: https://specifications.freedesktop.org/desktop-entry-spec/latest/
+
+const DesktopEntryGroupName = "Desktop Entry"
+const DesktopActionGroupPrefix = "Desktop Action "
+
+type DesktopEntry struct {
+	// comments and blank lines before the first group
+	Header []string
+	Groups []*DesktopEntryGroup
+}
+
+type DesktopEntryGroup struct {
+	Name  string
+	Lines []*DesktopEntryLine
+}
+
+// Lines without a key are comments or blank lines and are kept verbatim.
+// Values are stored escaped and trimmed, the original text of a parsed line
+// is kept in Raw so that untouched lines serialize byte-for-byte.
+type DesktopEntryLine struct {
+	Key    string
+	Locale string
+	Value  string
+	Raw    string
+}
+
+func ParseDesktopEntry(content string) (*DesktopEntry, error) {
+	entry := &DesktopEntry{
+		Header: []string{},
+		Groups: []*DesktopEntryGroup{},
+	}
+	var group *DesktopEntryGroup
+	content = strings.ReplaceAll(content, "\r\n", "\n")
+	content = strings.TrimSuffix(content, "\n")
+	for i, raw := range strings.Split(content, "\n") {
+		line := strings.TrimSpace(raw)
+		if line == "" || strings.HasPrefix(line, "#") {
+			if group == nil {
+				entry.Header = append(entry.Header, raw)
+			} else {
+				group.Lines = append(group.Lines, &DesktopEntryLine{Raw: raw})
+			}
+			continue
+		}
+		if strings.HasPrefix(line, "[") {
+			if !strings.HasSuffix(line, "]") {
+				return nil, fmt.Errorf("invalid group header at line %d", i+1)
+			}
+			name := line[1 : len(line)-1]
+			if name == "" || strings.ContainsAny(name, "[]") {
+				return nil, fmt.Errorf("invalid group name at line %d", i+1)
+			}
+			if entry.Group(name) != nil {
+				return nil, fmt.Errorf("duplicate group %s at line %d", name, i+1)
+			}
+			group = &DesktopEntryGroup{
+				Name:  name,
+				Lines: []*DesktopEntryLine{},
+			}
+			entry.Groups = append(entry.Groups, group)
+			continue
+		}
+		if group == nil {
+			return nil, fmt.Errorf("key outside of a group at line %d", i+1)
+		}
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid key-value pair at line %d", i+1)
+		}
+		key = strings.TrimSpace(key)
+		locale := ""
+		if start := strings.IndexByte(key, '['); start != -1 {
+			if !strings.HasSuffix(key, "]") {
+				return nil, fmt.Errorf("invalid localized key at line %d", i+1)
+			}
+			locale = key[start+1 : len(key)-1]
+			key = key[:start]
+		}
+		if key == "" {
+			return nil, fmt.Errorf("empty key at line %d", i+1)
+		}
+		group.Lines = append(group.Lines, &DesktopEntryLine{
+			Key:    key,
+			Locale: locale,
+			Value:  strings.TrimSpace(value),
+			Raw:    raw,
+		})
+	}
+	return entry, nil
+}
+
+func (entry *DesktopEntry) Group(name string) *DesktopEntryGroup {
+	for _, x := range entry.Groups {
+		if x.Name == name {
+			return x
+		}
+	}
+	return nil
+}
+
+func (entry *DesktopEntry) MainGroup() (*DesktopEntryGroup, error) {
+	group := entry.Group(DesktopEntryGroupName)
+	if group == nil {
+		return nil, errors.New("missing [Desktop Entry] group")
+	}
+	return group, nil
+}
+
+func (entry *DesktopEntry) ActionGroups() []*DesktopEntryGroup {
+	groups := []*DesktopEntryGroup{}
+	for _, x := range entry.Groups {
+		if strings.HasPrefix(x.Name, DesktopActionGroupPrefix) {
+			groups = append(groups, x)
+		}
+	}
+	return groups
+}
+
+func (entry *DesktopEntry) String() string {
+	var text strings.Builder
+	for _, x := range entry.Header {
+		text.WriteString(x)
+		text.WriteString("\n")
+	}
+	for i, group := range entry.Groups {
+		if i > 0 || len(entry.Header) > 0 {
+			// avoid stacking blank lines that were preserved from the source
+			if !strings.HasSuffix(text.String(), "\n\n") {
+				text.WriteString("\n")
+			}
+		}
+		text.WriteString(fmt.Sprintf("[%s]\n", group.Name))
+		lines := group.Lines
+		for len(lines) > 0 && lines[len(lines)-1].isBlank() {
+			lines = lines[:len(lines)-1]
+		}
+		for _, x := range lines {
+			text.WriteString(x.String())
+			text.WriteString("\n")
+		}
+	}
+	return text.String()
+}
+
+func (line *DesktopEntryLine) String() string {
+	if line.Key == "" || line.Raw != "" {
+		return line.Raw
+	}
+	if line.Locale != "" {
+		return fmt.Sprintf("%s[%s]=%s", line.Key, line.Locale, line.Value)
+	}
+	return fmt.Sprintf("%s=%s", line.Key, line.Value)
+}
+
+func (line *DesktopEntryLine) isBlank() bool {
+	return line.Key == "" && strings.TrimSpace(line.Raw) == ""
+}
+
+func (group *DesktopEntryGroup) find(key string, locale string) *DesktopEntryLine {
+	for _, x := range group.Lines {
+		if x.Key == key && x.Locale == locale {
+			return x
+		}
+	}
+	return nil
+}
+
+func (group *DesktopEntryGroup) Has(key string) bool {
+	return group.find(key, "") != nil
+}
+
+func (group *DesktopEntryGroup) Get(key string) (string, bool) {
+	return group.GetLocalized(key, "")
+}
+
+func (group *DesktopEntryGroup) GetLocalized(key string, locale string) (string, bool) {
+	line := group.find(key, locale)
+	if line == nil {
+		return "", false
+	}
+	return UnescapeDesktopEntryValue(line.Value), true
+}
+
+func (group *DesktopEntryGroup) Set(key string, value string) {
+	group.SetLocalized(key, "", value)
+}
+
+func (group *DesktopEntryGroup) SetLocalized(key string, locale string, value string) {
+	group.setRaw(key, locale, EscapeDesktopEntryValue(value))
+}
+
+func (group *DesktopEntryGroup) setRaw(key string, locale string, value string) {
+	if line := group.find(key, locale); line != nil {
+		line.Value = value
+		line.Raw = ""
+		return
+	}
+	line := &DesktopEntryLine{
+		Key:    key,
+		Locale: locale,
+		Value:  value,
+	}
+	// insert after the last key so trailing comments stay trailing
+	at := len(group.Lines)
+	for at > 0 && group.Lines[at-1].Key == "" {
+		at--
+	}
+	group.Lines = append(group.Lines[:at], append([]*DesktopEntryLine{line}, group.Lines[at:]...)...)
+}
+
+// Removes the key along with all of its localized variants.
+func (group *DesktopEntryGroup) Delete(key string) {
+	lines := []*DesktopEntryLine{}
+	for _, x := range group.Lines {
+		if x.Key != key {
+			lines = append(lines, x)
+		}
+	}
+	group.Lines = lines
+}
+
+func (group *DesktopEntryGroup) GetList(key string) ([]string, bool) {
+	line := group.find(key, "")
+	if line == nil {
+		return nil, false
+	}
+	return SplitDesktopEntryList(line.Value), true
+}
+
+func (group *DesktopEntryGroup) SetList(key string, values []string) {
+	group.setRaw(key, "", JoinDesktopEntryList(values))
+}
+
+func (group *DesktopEntryGroup) GetBool(key string) (bool, bool) {
+	value, ok := group.Get(key)
+	if !ok {
+		return false, false
+	}
+	return value == "true", true
+}
+
+func (group *DesktopEntryGroup) SetBool(key string, value bool) {
+	if value {
+		group.setRaw(key, "", "true")
+	} else {
+		group.setRaw(key, "", "false")
+	}
+}
+
+func EscapeDesktopEntryValue(value string) string {
+	var text strings.Builder
+	for i, x := range value {
+		switch x {
+		case '\\':
+			text.WriteString(`\\`)
+		case '\n':
+			text.WriteString(`\n`)
+		case '\t':
+			text.WriteString(`\t`)
+		case '\r':
+			text.WriteString(`\r`)
+		case ' ':
+			// only surrounding spaces would be lost while parsing
+			if i == 0 || i == len(value)-1 {
+				text.WriteString(`\s`)
+			} else {
+				text.WriteRune(x)
+			}
+		default:
+			text.WriteRune(x)
+		}
+	}
+	return text.String()
+}
+
+func UnescapeDesktopEntryValue(value string) string {
+	var text strings.Builder
+	escaped := false
+	for _, x := range value {
+		if !escaped {
+			if x == '\\' {
+				escaped = true
+			} else {
+				text.WriteRune(x)
+			}
+			continue
+		}
+		escaped = false
+		switch x {
+		case 's':
+			text.WriteRune(' ')
+		case 'n':
+			text.WriteRune('\n')
+		case 't':
+			text.WriteRune('\t')
+		case 'r':
+			text.WriteRune('\r')
+		case '\\':
+			text.WriteRune('\\')
+		case ';':
+			text.WriteRune(';')
+		default:
+			text.WriteRune('\\')
+			text.WriteRune(x)
+		}
+	}
+	if escaped {
+		text.WriteRune('\\')
+	}
+	return text.String()
+}
+
+// Values are split before they are unescaped, so that an escaped backslash
+// in front of a separator is not mistaken for an escaped separator.
+func SplitDesktopEntryList(raw string) []string {
+	values := []string{}
+	var current strings.Builder
+	escaped := false
+	for _, x := range raw {
+		switch {
+		case escaped:
+			escaped = false
+			current.WriteRune('\\')
+			current.WriteRune(x)
+		case x == '\\':
+			escaped = true
+		case x == ';':
+			values = append(values, UnescapeDesktopEntryValue(current.String()))
+			current.Reset()
+		default:
+			current.WriteRune(x)
+		}
+	}
+	if escaped {
+		current.WriteRune('\\')
+	}
+	if current.Len() > 0 {
+		values = append(values, UnescapeDesktopEntryValue(current.String()))
+	}
+	return values
+}
+
+func JoinDesktopEntryList(values []string) string {
+	var text strings.Builder
+	for _, x := range values {
+		x = EscapeDesktopEntryValue(x)
+		text.WriteString(strings.ReplaceAll(x, ";", `\;`))
+		text.WriteString(";")
+	}
+	return text.String()
+}
+
+// Splits an already unescaped Exec value into arguments following the
+// quoting rules of the specification. Field codes are left untouched.
+func ParseDesktopExec(value string) ([]string, error) {
+	args := []string{}
+	var current strings.Builder
+	hasCurrent := false
+	quoted := false
+	escaped := false
+	for _, x := range value {
+		switch {
+		case escaped:
+			escaped = false
+			current.WriteRune(x)
+		case quoted && x == '\\':
+			escaped = true
+		case x == '"':
+			quoted = !quoted
+			hasCurrent = true
+		case !quoted && (x == ' ' || x == '\t' || x == '\n'):
+			if hasCurrent {
+				args = append(args, current.String())
+				current.Reset()
+				hasCurrent = false
+			}
+		default:
+			current.WriteRune(x)
+			hasCurrent = true
+		}
+	}
+	if quoted || escaped {
+		return nil, errors.New("unterminated quote in exec value")
+	}
+	if hasCurrent {
+		args = append(args, current.String())
+	}
+	return args, nil
+}
+
+const desktopExecReservedChars = " \t\n\"'\\><~|&;$*?#()`"
+
+// Quotes the arguments, which are expected in their field code form, see
+// EscapeDesktopExecArg.
+func FormatDesktopExec(args []string) string {
+	formatted := []string{}
+	for _, x := range args {
+		if x != "" && !strings.ContainsAny(x, desktopExecReservedChars) {
+			formatted = append(formatted, x)
+			continue
+		}
+		var text strings.Builder
+		text.WriteRune('"')
+		for _, y := range x {
+			if strings.ContainsRune("\"`$\\", y) {
+				text.WriteRune('\\')
+			}
+			text.WriteRune(y)
+		}
+		text.WriteRune('"')
+		formatted = append(formatted, text.String())
+	}
+	return strings.Join(formatted, " ")
+}
+
+// Escapes a literal argument so that the launcher does not expand its
+// percent signs as field codes.
+func EscapeDesktopExecArg(arg string) string {
+	return strings.ReplaceAll(arg, "%", "%%")
+}
+
+func (group *DesktopEntryGroup) GetExec(key string) ([]string, bool, error) {
+	value, ok := group.Get(key)
+	if !ok {
+		return nil, false, nil
+	}
+	args, err := ParseDesktopExec(value)
+	if err != nil {
+		return nil, true, err
+	}
+	return args, true, nil
+}
+
+func (group *DesktopEntryGroup) SetExec(key string, args []string) {
+	group.Set(key, FormatDesktopExec(args))
+}
+
+// Returns the index of the executable, skipping a leading `env` and
+// its variable assignments.
+func DesktopExecProgramIndex(args []string) int {
+	if len(args) == 0 || args[0] != "env" {
+		return 0
+	}
+	i := 1
+	for i < len(args) && strings.Contains(args[i], "=") && !strings.HasPrefix(args[i], "=") {
+		i++
+	}
+	return i
+}
@@ -0,0 +1,273 @@
+package core
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseDesktopEntryRoundTrip(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+	}{
+		{
+			name:    "comments and blank lines",
+			content: "# header\n\n[Desktop Entry]\nName=Foo\n# comment\nExec=foo %U\n\n[Desktop Action new]\nName=New\nExec=foo --new\n",
+		},
+		{
+			name:    "localized keys and spacing",
+			content: "[Desktop Entry]\nName = Foo\nName[de]=Fuu\nComment[pt_BR]=Um   texto\n",
+		},
+		{
+			name:    "escaped values",
+			content: "[Desktop Entry]\nName=\\sFoo\\tBar\\\\\nKeywords=a\\;b;c;\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entry, err := ParseDesktopEntry(tt.content)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := entry.String(); got != tt.content {
+				t.Errorf("got %q, want %q", got, tt.content)
+			}
+		})
+	}
+}
+
+func TestParseDesktopEntryErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+	}{
+		{name: "key outside of a group", content: "Name=Foo\n"},
+		{name: "unterminated group header", content: "[Desktop Entry\n"},
+		{name: "duplicate group", content: "[Desktop Entry]\n[Desktop Entry]\n"},
+		{name: "missing separator", content: "[Desktop Entry]\nName\n"},
+		{name: "invalid localized key", content: "[Desktop Entry]\nName[de=Fuu\n"},
+		{name: "empty key", content: "[Desktop Entry]\n=Foo\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ParseDesktopEntry(tt.content); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
+
+func TestDesktopEntryGroupGet(t *testing.T) {
+	entry, err := ParseDesktopEntry("[Desktop Entry]\nName=\\sFoo\\nBar\nName[de]=Fuu\nPath=C:\\\\x\nOther=a\\qb\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	group, err := entry.MainGroup()
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		key    string
+		locale string
+		want   string
+	}{
+		{key: "Name", want: " Foo\nBar"},
+		{key: "Name", locale: "de", want: "Fuu"},
+		{key: "Path", want: `C:\x`},
+		{key: "Other", want: `a\qb`},
+	}
+	for _, tt := range tests {
+		got, ok := group.GetLocalized(tt.key, tt.locale)
+		if !ok || got != tt.want {
+			t.Errorf("%s[%s]: got %q, want %q", tt.key, tt.locale, got, tt.want)
+		}
+	}
+}
+
+func TestDesktopEntryValueEscaping(t *testing.T) {
+	tests := []struct {
+		value string
+		want  string
+	}{
+		{value: "foo bar", want: "foo bar"},
+		{value: " foo ", want: `\sfoo\s`},
+		{value: "a\nb\tc\rd", want: `a\nb\tc\rd`},
+		{value: `a\b`, want: `a\\b`},
+	}
+	for _, tt := range tests {
+		got := EscapeDesktopEntryValue(tt.value)
+		if got != tt.want {
+			t.Errorf("EscapeDesktopEntryValue(%q): got %q, want %q", tt.value, got, tt.want)
+		}
+		if back := UnescapeDesktopEntryValue(got); back != tt.value {
+			t.Errorf("UnescapeDesktopEntryValue(%q): got %q, want %q", got, back, tt.value)
+		}
+	}
+}
+
+func TestSplitDesktopEntryList(t *testing.T) {
+	tests := []struct {
+		raw  string
+		want []string
+	}{
+		{raw: "", want: []string{}},
+		{raw: "a;b;c;", want: []string{"a", "b", "c"}},
+		{raw: "a;b", want: []string{"a", "b"}},
+		{raw: `a\;b;c;`, want: []string{"a;b", "c"}},
+		{raw: `a\sb;\tc;`, want: []string{"a b", "\tc"}},
+		{raw: `x\\;y;`, want: []string{`x\`, "y"}},
+		{raw: `x\\\;y;`, want: []string{`x\;y`}},
+		{raw: "a;;b;", want: []string{"a", "", "b"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.raw, func(t *testing.T) {
+			got := SplitDesktopEntryList(tt.raw)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestJoinDesktopEntryList(t *testing.T) {
+	tests := []struct {
+		values []string
+		want   string
+	}{
+		{values: []string{}, want: ""},
+		{values: []string{"a", "b"}, want: "a;b;"},
+		{values: []string{"a;b", "c"}, want: `a\;b;c;`},
+		{values: []string{`x\`, " y"}, want: `x\\;\sy;`},
+	}
+	for _, tt := range tests {
+		got := JoinDesktopEntryList(tt.values)
+		if got != tt.want {
+			t.Errorf("JoinDesktopEntryList(%q): got %q, want %q", tt.values, got, tt.want)
+		}
+		if back := SplitDesktopEntryList(got); !reflect.DeepEqual(back, tt.values) {
+			t.Errorf("SplitDesktopEntryList(%q): got %q, want %q", got, back, tt.values)
+		}
+	}
+}
+
+func TestParseDesktopExec(t *testing.T) {
+	tests := []struct {
+		value string
+		want  []string
+	}{
+		{value: "foo", want: []string{"foo"}},
+		{value: "foo %U", want: []string{"foo", "%U"}},
+		{value: "  foo \t --bar  ", want: []string{"foo", "--bar"}},
+		{value: `"/opt/my app/run" --flag`, want: []string{"/opt/my app/run", "--flag"}},
+		{value: `sh -c "echo \"hi\" \$HOME \\ \` + "`" + `x\` + "`" + `"`, want: []string{"sh", "-c", "echo \"hi\" $HOME \\ `x`"}},
+		{value: `foo ""`, want: []string{"foo", ""}},
+		{value: `foo --file=%f %%`, want: []string{"foo", "--file=%f", "%%"}},
+		{value: `env A=1 "B=two words" foo`, want: []string{"env", "A=1", "B=two words", "foo"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, err := ParseDesktopExec(tt.value)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseDesktopExecErrors(t *testing.T) {
+	for _, value := range []string{`"foo`, `foo "bar`, `"foo\`} {
+		if _, err := ParseDesktopExec(value); err == nil {
+			t.Errorf("%q: expected an error", value)
+		}
+	}
+}
+
+func TestFormatDesktopExec(t *testing.T) {
+	tests := []struct {
+		args []string
+		want string
+	}{
+		{args: []string{"foo", "%U"}, want: "foo %U"},
+		{args: []string{"/opt/my app/run"}, want: `"/opt/my app/run"`},
+		{args: []string{"foo", ""}, want: `foo ""`},
+		{args: []string{"sh", "-c", "echo \"$HOME\" `x` \\"}, want: `sh -c "echo \"\$HOME\" \` + "`" + `x\` + "`" + ` \\"`},
+		{args: []string{"a;b", "c'd", "e>f"}, want: `"a;b" "c'd" "e>f"`},
+	}
+	for _, tt := range tests {
+		got := FormatDesktopExec(tt.args)
+		if got != tt.want {
+			t.Errorf("FormatDesktopExec(%q): got %q, want %q", tt.args, got, tt.want)
+		}
+		back, err := ParseDesktopExec(got)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(back, tt.args) {
+			t.Errorf("ParseDesktopExec(%q): got %q, want %q", got, back, tt.args)
+		}
+	}
+}
+
+func TestEscapeDesktopExecArg(t *testing.T) {
+	tests := []struct {
+		arg  string
+		want string
+	}{
+		{arg: "--rate=50%", want: "--rate=50%%"},
+		{arg: "%U", want: "%%U"},
+		{arg: "foo", want: "foo"},
+	}
+	for _, tt := range tests {
+		if got := EscapeDesktopExecArg(tt.arg); got != tt.want {
+			t.Errorf("EscapeDesktopExecArg(%q): got %q, want %q", tt.arg, got, tt.want)
+		}
+	}
+}
+
+func TestDesktopExecProgramIndex(t *testing.T) {
+	tests := []struct {
+		args []string
+		want int
+	}{
+		{args: []string{}, want: 0},
+		{args: []string{"foo", "A=1"}, want: 0},
+		{args: []string{"env", "foo"}, want: 1},
+		{args: []string{"env", "A=1", "B=2", "foo", "C=3"}, want: 3},
+		{args: []string{"env", "=x", "foo"}, want: 1},
+	}
+	for _, tt := range tests {
+		if got := DesktopExecProgramIndex(tt.args); got != tt.want {
+			t.Errorf("DesktopExecProgramIndex(%q): got %d, want %d", tt.args, got, tt.want)
+		}
+	}
+}
+
+func TestRewriteDesktopExec(t *testing.T) {
+	prefix := []string{"env", "APPIMAGELAUNCHER_DISABLE=1", "/apps/foo/foo.AppImage", "--no-sandbox"}
+	tests := []struct {
+		exec string
+		want string
+	}{
+		{exec: "foo %U", want: "env APPIMAGELAUNCHER_DISABLE=1 /apps/foo/foo.AppImage --no-sandbox %U"},
+		{exec: "env GDK_BACKEND=x11 foo %F", want: "env GDK_BACKEND=x11 APPIMAGELAUNCHER_DISABLE=1 /apps/foo/foo.AppImage --no-sandbox %F"},
+		{exec: `"/usr/bin/my foo" --new-window`, want: "env APPIMAGELAUNCHER_DISABLE=1 /apps/foo/foo.AppImage --no-sandbox --new-window"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.exec, func(t *testing.T) {
+			entry, err := ParseDesktopEntry("[Desktop Entry]\nExec=" + tt.exec + "\n")
+			if err != nil {
+				t.Fatal(err)
+			}
+			group, _ := entry.MainGroup()
+			if err = rewriteDesktopExec(group, prefix); err != nil {
+				t.Fatal(err)
+			}
+			if got, _ := group.Get("Exec"); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,340 @@
+package core
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/zyrouge/pho/utils"
+	"golang.org/x/crypto/blake2b"
+)
+
+type SignatureScheme string
+
+const (
+	SignatureSchemeOpenPgp  SignatureScheme = "openpgp"
+	SignatureSchemeMinisign SignatureScheme = "minisign"
+	SignatureSchemeCosign   SignatureScheme = "cosign"
+)
+
+var signatureSchemeExtensions = map[SignatureScheme][]string{
+	SignatureSchemeOpenPgp:  {".asc", ".sig", ".gpg"},
+	SignatureSchemeMinisign: {".minisig"},
+	SignatureSchemeCosign:   {".sig"},
+}
+
+// Trusted public key of a source, used to verify detached signatures that
+// are published next to the assets.
+type SourceSignature struct {
+	// armored OpenPGP key, minisign public key or PEM encoded public key
+	PublicKey string `json:"PublicKey"`
+}
+
+func NewSourceSignature(publicKey string) (*SourceSignature, error) {
+	signature := &SourceSignature{
+		PublicKey: strings.TrimSpace(publicKey),
+	}
+	scheme, err := signature.Scheme()
+	if err != nil {
+		return nil, err
+	}
+	switch scheme {
+	case SignatureSchemeOpenPgp:
+		_, err = openpgp.ReadArmoredKeyRing(strings.NewReader(signature.PublicKey))
+
+	case SignatureSchemeMinisign:
+		_, _, err = parseMinisignPublicKey(signature.PublicKey)
+
+	case SignatureSchemeCosign:
+		_, err = parsePemPublicKey(signature.PublicKey)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s public key: %v", scheme, err)
+	}
+	return signature, nil
+}
+
+func ReadSourceSignature(name string) (*SourceSignature, error) {
+	name, err := utils.ResolvePath(name)
+	if err != nil {
+		return nil, err
+	}
+	content, err := os.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+	return NewSourceSignature(string(content))
+}
+
+func (signature *SourceSignature) Scheme() (SignatureScheme, error) {
+	key := signature.PublicKey
+	switch {
+	case strings.Contains(key, "-----BEGIN PGP PUBLIC KEY BLOCK-----"):
+		return SignatureSchemeOpenPgp, nil
+
+	case strings.Contains(key, "-----BEGIN PUBLIC KEY-----"):
+		return SignatureSchemeCosign, nil
+
+	case strings.HasPrefix(lastNonEmptyLine(key), "RW"):
+		return SignatureSchemeMinisign, nil
+	}
+	return "", errors.New("unsupported public key")
+}
+
+func (signature *SourceSignature) Extensions() []string {
+	scheme, _ := signature.Scheme()
+	return signatureSchemeExtensions[scheme]
+}
+
+// Verifies the detached signature of a file. The trusted key itself is the
+// pin, any key of an OpenPGP key ring is accepted as the signer.
+func (signature *SourceSignature) Verify(name string, data []byte) error {
+	scheme, err := signature.Scheme()
+	if err != nil {
+		return err
+	}
+	switch scheme {
+	case SignatureSchemeOpenPgp:
+		err = verifyOpenPgpSignature(signature.PublicKey, name, data)
+
+	case SignatureSchemeMinisign:
+		err = verifyMinisignSignature(signature.PublicKey, name, data)
+
+	case SignatureSchemeCosign:
+		err = verifyCosignSignature(signature.PublicKey, name, data)
+	}
+	if err != nil {
+		return fmt.Errorf("invalid %s signature: %v", scheme, err)
+	}
+	return nil
+}
+
+// Tells apart the formats of detached signatures, as both OpenPGP and cosign
+// signatures are published with the .sig extension.
+func DetectSignatureScheme(data []byte) (SignatureScheme, error) {
+	text := strings.TrimSpace(string(data))
+	switch {
+	case strings.HasPrefix(text, "-----BEGIN PGP SIGNATURE-----"):
+		return SignatureSchemeOpenPgp, nil
+
+	case strings.HasPrefix(text, "untrusted comment:"):
+		return SignatureSchemeMinisign, nil
+
+	case len(data) > 0 && data[0]&0x80 != 0:
+		// binary OpenPGP packets always have the high bit of their tag set
+		return SignatureSchemeOpenPgp, nil
+	}
+	if _, err := base64.StdEncoding.DecodeString(text); err == nil && text != "" {
+		return SignatureSchemeCosign, nil
+	}
+	return "", errors.New("unsupported signature format")
+}
+
+// Returns the first signature of the scheme that exists among the
+// candidates, signatures of other schemes sharing an extension are skipped.
+func FetchDetachedSignature(urls []string, scheme SignatureScheme) ([]byte, error) {
+	for _, url := range urls {
+		res, err := http.Get(url)
+		if err != nil {
+			return nil, err
+		}
+		if res.StatusCode == 404 {
+			res.Body.Close()
+			continue
+		}
+		if res.StatusCode != 200 {
+			res.Body.Close()
+			return nil, fmt.Errorf("signature %s returned status %d", url, res.StatusCode)
+		}
+		data, err := io.ReadAll(io.LimitReader(res.Body, 1<<20))
+		res.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		if detected, err := DetectSignatureScheme(data); err != nil || detected != scheme {
+			continue
+		}
+		return data, nil
+	}
+	return nil, fmt.Errorf("no %s detached signature found", scheme)
+}
+
+func verifyOpenPgpSignature(publicKey string, name string, signature []byte) error {
+	keyring, err := openpgp.ReadArmoredKeyRing(strings.NewReader(publicKey))
+	if err != nil {
+		return err
+	}
+	file, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	if bytes.Contains(signature, []byte("-----BEGIN PGP SIGNATURE-----")) {
+		_, err = openpgp.CheckArmoredDetachedSignature(keyring, file, bytes.NewReader(signature), nil)
+	} else {
+		_, err = openpgp.CheckDetachedSignature(keyring, file, bytes.NewReader(signature), nil)
+	}
+	return err
+}
+
+// Reference: https://jedisct1.github.io/minisign/#signature-format
+func parseMinisignPublicKey(publicKey string) ([]byte, ed25519.PublicKey, error) {
+	decoded, err := base64.StdEncoding.DecodeString(lastNonEmptyLine(publicKey))
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(decoded) != 42 || string(decoded[:2]) != "Ed" {
+		return nil, nil, errors.New("unsupported key format")
+	}
+	return decoded[2:10], ed25519.PublicKey(decoded[10:]), nil
+}
+
+func verifyMinisignSignature(publicKey string, name string, signature []byte) error {
+	keyId, key, err := parseMinisignPublicKey(publicKey)
+	if err != nil {
+		return err
+	}
+	lines := strings.Split(strings.TrimSpace(string(signature)), "\n")
+	if len(lines) < 4 {
+		return errors.New("malformed signature file")
+	}
+	decoded, err := base64.StdEncoding.DecodeString(strings.TrimSpace(lines[1]))
+	if err != nil {
+		return err
+	}
+	if len(decoded) != 74 {
+		return errors.New("malformed signature")
+	}
+	algorithm := string(decoded[:2])
+	if !bytes.Equal(decoded[2:10], keyId) {
+		return errors.New("signed by a different key")
+	}
+	var message []byte
+	switch algorithm {
+	case "ED":
+		hash, _ := blake2b.New512(nil)
+		message, err = hashFile(name, hash)
+
+	case "Ed":
+		message, err = readUnhashedSignedFile(name)
+
+	default:
+		return fmt.Errorf("unsupported algorithm %s", algorithm)
+	}
+	if err != nil {
+		return err
+	}
+	if !ed25519.Verify(key, message, decoded[10:]) {
+		return errors.New("signature verification failed")
+	}
+	trustedComment, ok := strings.CutPrefix(strings.TrimRight(lines[2], "\r"), "trusted comment: ")
+	if !ok {
+		return errors.New("malformed trusted comment")
+	}
+	globalSignature, err := base64.StdEncoding.DecodeString(strings.TrimSpace(lines[3]))
+	if err != nil {
+		return err
+	}
+	if !ed25519.Verify(key, append(append([]byte{}, decoded[10:]...), trustedComment...), globalSignature) {
+		return errors.New("trusted comment verification failed")
+	}
+	return nil
+}
+
+func parsePemPublicKey(publicKey string) (crypto.PublicKey, error) {
+	block, _ := pem.Decode([]byte(publicKey))
+	if block == nil {
+		return nil, errors.New("malformed pem block")
+	}
+	return x509.ParsePKIXPublicKey(block.Bytes)
+}
+
+// Signatures created by `cosign sign-blob` are base64 encoded.
+func verifyCosignSignature(publicKey string, name string, signature []byte) error {
+	key, err := parsePemPublicKey(publicKey)
+	if err != nil {
+		return err
+	}
+	if decoded, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(signature))); err == nil {
+		signature = decoded
+	}
+	verified := false
+	switch key := key.(type) {
+	case *ecdsa.PublicKey:
+		digest, err := hashFile(name, sha256.New())
+		if err != nil {
+			return err
+		}
+		verified = ecdsa.VerifyASN1(key, digest, signature)
+
+	case *rsa.PublicKey:
+		digest, err := hashFile(name, sha256.New())
+		if err != nil {
+			return err
+		}
+		verified = rsa.VerifyPKCS1v15(key, crypto.SHA256, digest, signature) == nil
+
+	case ed25519.PublicKey:
+		content, err := readUnhashedSignedFile(name)
+		if err != nil {
+			return err
+		}
+		verified = ed25519.Verify(key, content, signature)
+
+	default:
+		return errors.New("unsupported key type")
+	}
+	if !verified {
+		return errors.New("signature verification failed")
+	}
+	return nil
+}
+
+func hashFile(name string, hash hash.Hash) ([]byte, error) {
+	file, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	if _, err = io.Copy(hash, file); err != nil {
+		return nil, err
+	}
+	return hash.Sum(nil), nil
+}
+
+// Signatures that are not prehashed sign the whole file, which has to be
+// read into memory.
+const maxUnhashedSignedFileSize = 64 * 1024 * 1024
+
+func readUnhashedSignedFile(name string) ([]byte, error) {
+	stat, err := os.Stat(name)
+	if err != nil {
+		return nil, err
+	}
+	if stat.Size() > maxUnhashedSignedFileSize {
+		return nil, fmt.Errorf(
+			"file is too large to verify a signature that is not prehashed (%d bytes), sign it with a prehashed algorithm instead",
+			stat.Size(),
+		)
+	}
+	return os.ReadFile(name)
+}
+
+func lastNonEmptyLine(content string) string {
+	lines := strings.Split(strings.TrimSpace(content), "\n")
+	return strings.TrimSpace(lines[len(lines)-1])
+}
@@ -0,0 +1,91 @@
+package core
+
+import (
+	"debug/elf"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/zyrouge/pho/utils"
+)
+
+var libFuse2Dirs = []string{
+	"/lib",
+	"/lib64",
+	"/usr/lib",
+	"/usr/lib64",
+	"/lib/*-linux-gnu*",
+	"/usr/lib/*-linux-gnu*",
+}
+
+// AppImages mount themselves using FUSE, which is commonly unavailable in
+// containers and on hardened systems. Returns why the AppImage cannot be
+// mounted, or nil when it most likely can.
+func CheckFuseAvailable(appImagePath string) error {
+	// the host cannot run the runtime of another architecture at all
+	foreign, err := isForeignAppImage(appImagePath)
+	if err != nil {
+		return err
+	}
+	if foreign {
+		return errors.New("appimage is built for another architecture")
+	}
+	if err := CheckFuseDevice(); err != nil {
+		return err
+	}
+	if !HasFusermount() {
+		return errors.New("fusermount is not installed")
+	}
+	// static runtimes bundle libfuse, older dynamic ones load libfuse.so.2
+	dynamic, err := isDynamicExecutable(appImagePath)
+	if err != nil {
+		return err
+	}
+	if dynamic && !HasLibFuse2() {
+		return errors.New("libfuse.so.2 is not installed")
+	}
+	return nil
+}
+
+func CheckFuseDevice() error {
+	device, err := os.OpenFile("/dev/fuse", os.O_RDWR, 0)
+	if err != nil {
+		return err
+	}
+	return device.Close()
+}
+
+func HasFusermount() bool {
+	return utils.HasExecutable("fusermount") || utils.HasExecutable("fusermount3")
+}
+
+func isDynamicExecutable(name string) (bool, error) {
+	file, err := elf.Open(name)
+	if err != nil {
+		return false, err
+	}
+	defer file.Close()
+	for _, x := range file.Progs {
+		if x.Type == elf.PT_INTERP {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func HasLibFuse2() bool {
+	if output, err := exec.Command("ldconfig", "-p").Output(); err == nil {
+		if strings.Contains(string(output), "libfuse.so.2") {
+			return true
+		}
+	}
+	for _, x := range libFuse2Dirs {
+		matches, _ := filepath.Glob(filepath.Join(x, "libfuse.so.2*"))
+		if len(matches) > 0 {
+			return true
+		}
+	}
+	return false
+}
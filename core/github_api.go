@@ -23,6 +23,7 @@ type GithubApiReleaseAsset struct {
 	DownloadUrl string `json:"browser_download_url"`
 	Name        string `json:"name"`
 	Size        int64  `json:"size"`
+	Digest      string `json:"digest"`
 }
 
 func GithubApiFetchReleases(username string, reponame string) (*[]GithubApiRelease, error) {
@@ -80,6 +81,65 @@ func (asset *GithubApiReleaseAsset) ToAsset() *Asset {
 	}
 }
 
+// Looks up the digest of an asset from the api, or from a checksum file
+// published in the same release.
+func (release *GithubApiRelease) FindAssetSha256(asset *GithubApiReleaseAsset) (string, error) {
+	if strings.HasPrefix(asset.Digest, Sha256DigestPrefix) {
+		return NormalizeSha256(asset.Digest)
+	}
+	candidates := []*GithubApiReleaseAsset{}
+	for i := range release.Assets {
+		x := &release.Assets[i]
+		name := strings.ToLower(x.Name)
+		if name == strings.ToLower(asset.Name)+".sha256" {
+			candidates = append([]*GithubApiReleaseAsset{x}, candidates...)
+			continue
+		}
+		if strings.HasPrefix(name, "sha256sums") {
+			candidates = append(candidates, x)
+		}
+	}
+	for _, x := range candidates {
+		digest, err := FetchSha256Sums(x.DownloadUrl, asset.Name)
+		if err != nil {
+			return "", err
+		}
+		if digest != "" {
+			return digest, nil
+		}
+	}
+	return "", nil
+}
+
+func (release *GithubApiRelease) FindAssetSignatureUrls(asset *GithubApiReleaseAsset, signature *SourceSignature) []string {
+	urls := []string{}
+	for _, ext := range signature.Extensions() {
+		for _, x := range release.Assets {
+			if x.Name == asset.Name+ext {
+				urls = append(urls, x.DownloadUrl)
+			}
+		}
+	}
+	return urls
+}
+
+func (release *GithubApiRelease) ToVerifiedAsset(asset *GithubApiReleaseAsset, signature *SourceSignature) (*Asset, error) {
+	digest, err := release.FindAssetSha256(asset)
+	if err != nil {
+		return nil, err
+	}
+	verified := asset.ToAsset()
+	verified.Sha256 = digest
+	if signature != nil {
+		verified.Signature = signature
+		verified.SignatureUrls = release.FindAssetSignatureUrls(asset, signature)
+		if len(verified.SignatureUrls) == 0 {
+			return nil, fmt.Errorf("no detached signature of %s in github tag %s", asset.Name, release.TagName)
+		}
+	}
+	return verified, nil
+}
+
 var GithubRepoUrlRegex = regexp.MustCompile(`^([^\/]+)\/([^\/]+)$`)
 
 func ParseGithubRepoUrl(url string) (bool, string, string) {
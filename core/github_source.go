@@ -3,8 +3,6 @@ package core
 import (
 	"errors"
 	"fmt"
-
-	"github.com/zyrouge/pho/utils"
 )
 
 const GithubSourceId SourceId = "github"
@@ -23,10 +21,12 @@ type GithubSource struct {
 	RepoName string              `json:"RepoName"`
 	Release  GithubSourceRelease `json:"Release"`
 	TagName  string              `json:"TagName"`
+	// assets must carry a detached signature by this key when set
+	Signature *SourceSignature `json:"Signature"`
 }
 
 func ReadGithubSourceConfig(configPath string) (*GithubSource, error) {
-	return utils.ReadJsonFile[GithubSource](configPath)
+	return ReadVersionedJsonFile[GithubSource](configPath, SourceConfigSchema)
 }
 
 func (source *GithubSource) FetchAptRelease() (*GithubApiRelease, error) {
@@ -52,9 +52,10 @@ func (source *GithubSource) FetchAptLatestRelease() (*GithubApiRelease, error) {
 	}
 }
 
-func (release *GithubApiRelease) ChooseAptAsset() (AppImageAssetMatch, *GithubApiReleaseAsset) {
+func (release *GithubApiRelease) ChooseAptAsset(arch string) (AppImageAssetMatch, *GithubApiReleaseAsset) {
 	return ChooseAptAppImageAsset(
 		release.Assets,
+		arch,
 		func(x *GithubApiReleaseAsset) string {
 			return x.Name
 		},
@@ -73,14 +74,22 @@ func (source *GithubSource) CheckUpdate(app *AppConfig, reinstall bool) (*Source
 	if app.Version == release.TagName && !reinstall {
 		return nil, nil
 	}
-	matchScore, asset := release.ChooseAptAsset()
+	arch, err := GetTargetArch(app)
+	if err != nil {
+		return nil, err
+	}
+	matchScore, asset := release.ChooseAptAsset(arch)
 	if matchScore == AppImageAssetNoMatch {
 		return nil, fmt.Errorf("no valid asset in github tag %s", release.TagName)
 	}
+	verifiedAsset, err := release.ToVerifiedAsset(asset, source.Signature)
+	if err != nil {
+		return nil, err
+	}
 	update := &SourceUpdate{
 		Version:    release.TagName,
 		MatchScore: matchScore,
-		Asset:      asset.ToAsset(),
+		Asset:      verifiedAsset,
 	}
 	return update, nil
 }
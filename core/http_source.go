@@ -1,17 +1,16 @@
 package core
 
-import (
-	"errors"
-
-	"github.com/zyrouge/pho/utils"
-)
+import "errors"
 
 const HttpSourceId SourceId = "http"
 
-type HttpSource struct{}
+type HttpSource struct {
+	// assets must carry a detached signature by this key when set
+	Signature *SourceSignature `json:"Signature"`
+}
 
 func ReadHttpSourceConfig(configPath string) (*HttpSource, error) {
-	return utils.ReadJsonFile[HttpSource](configPath)
+	return ReadVersionedJsonFile[HttpSource](configPath, SourceConfigSchema)
 }
 
 func (*HttpSource) SupportsUpdates() bool {
@@ -21,3 +20,15 @@ func (*HttpSource) SupportsUpdates() bool {
 func (*HttpSource) CheckUpdate(app *AppConfig, reinstall bool) (*SourceUpdate, error) {
 	return nil, errors.New("http source does not support updates")
 }
+
+// Signatures are expected next to the asset, such as `<url>.asc`.
+func (source *HttpSource) GetSignatureUrls(url string) []string {
+	if source.Signature == nil {
+		return nil
+	}
+	urls := []string{}
+	for _, ext := range source.Signature.Extensions() {
+		urls = append(urls, url+ext)
+	}
+	return urls
+}
@@ -0,0 +1,258 @@
+package core
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/zyrouge/pho/utils"
+)
+
+// Reference: https://specifications.freedesktop.org/icon-theme-spec/latest/
+
+const IconThemeName = "hicolor"
+const IconThemeScalableSize = "scalable"
+
+var iconThemeSizes = []int{16, 22, 24, 32, 36, 48, 64, 72, 96, 128, 192, 256, 512}
+
+type IconFormat string
+
+const (
+	IconFormatUnknown IconFormat = ""
+	IconFormatPng     IconFormat = "png"
+	IconFormatSvg     IconFormat = "svg"
+	IconFormatXpm     IconFormat = "xpm"
+	IconFormatJpg     IconFormat = "jpg"
+)
+
+var pngMagic = []byte{0x89, 'P', 'N', 'G', '\r', '\n', 0x1a, '\n'}
+var jpgMagic = []byte{0xff, 0xd8, 0xff}
+
+func GetDefaultIconsDir() (string, error) {
+	if SystemMode {
+		return path.Join(SystemDataDir, "icons"), nil
+	}
+	dataDir, err := utils.GetXdgDataHome()
+	if err != nil {
+		return "", err
+	}
+	return path.Join(dataDir, "icons"), nil
+}
+
+func ConstructAppIconName(appId string) string {
+	return fmt.Sprintf("%s-%s", GetSharedNamePrefix(), appId)
+}
+
+func DetectIconFormat(name string) (IconFormat, error) {
+	file, err := os.Open(name)
+	if err != nil {
+		return IconFormatUnknown, err
+	}
+	defer file.Close()
+	header := make([]byte, 1024)
+	n, err := io.ReadFull(file, header)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) {
+		return IconFormatUnknown, err
+	}
+	header = header[:n]
+	switch {
+	case bytes.HasPrefix(header, pngMagic):
+		return IconFormatPng, nil
+
+	case bytes.HasPrefix(header, jpgMagic):
+		return IconFormatJpg, nil
+
+	case bytes.Contains(header, []byte("/* XPM */")):
+		return IconFormatXpm, nil
+
+	case bytes.Contains(header, []byte("<svg")):
+		return IconFormatSvg, nil
+	}
+	return IconFormatUnknown, nil
+}
+
+// Only formats supported by icon themes can be installed into them.
+func (format IconFormat) IsThemeable() bool {
+	return format == IconFormatPng || format == IconFormatSvg || format == IconFormatXpm
+}
+
+type ThemeIcon struct {
+	Size   string
+	Path   string
+	Format IconFormat
+}
+
+// Collects icons shipped at usr/share/icons/hicolor/<size>/apps inside the AppDir.
+func FindThemeIcons(appDir string, iconName string) ([]ThemeIcon, error) {
+	icons := []ThemeIcon{}
+	if iconName == "" {
+		return icons, nil
+	}
+	themeDir := path.Join(appDir, "usr/share/icons", IconThemeName)
+	sizes, err := os.ReadDir(themeDir)
+	if errors.Is(err, os.ErrNotExist) {
+		return icons, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	for _, x := range sizes {
+		size := x.Name()
+		if !isIconThemeSize(size) {
+			continue
+		}
+		for _, ext := range []IconFormat{IconFormatSvg, IconFormatPng, IconFormatXpm} {
+			iconPath := path.Join(themeDir, size, "apps", fmt.Sprintf("%s.%s", iconName, ext))
+			if _, err := os.Lstat(iconPath); errors.Is(err, os.ErrNotExist) {
+				continue
+			}
+			// symlinks are followed only within the app directory
+			iconPath, err := utils.ResolvePathInDir(appDir, iconPath)
+			if err != nil {
+				continue
+			}
+			format, err := DetectIconFormat(iconPath)
+			if err != nil {
+				return nil, err
+			}
+			if !format.IsThemeable() {
+				continue
+			}
+			icons = append(icons, ThemeIcon{
+				Size:   size,
+				Path:   iconPath,
+				Format: format,
+			})
+			break
+		}
+	}
+	return icons, nil
+}
+
+func isIconThemeSize(size string) bool {
+	if size == IconThemeScalableSize {
+		return true
+	}
+	dimensions, _, _ := strings.Cut(size, "@")
+	width, height, ok := strings.Cut(dimensions, "x")
+	if _, err := strconv.Atoi(width); err != nil {
+		return false
+	}
+	return ok && width == height
+}
+
+// Determines the theme directory of a standalone icon, empty when it does not fit into one.
+func GuessThemeIconSize(name string, format IconFormat) (string, error) {
+	switch format {
+	case IconFormatSvg:
+		return IconThemeScalableSize, nil
+
+	case IconFormatPng:
+		width, height, err := readPngDimensions(name)
+		if err != nil {
+			return "", err
+		}
+		if width != height || !utils.SliceContains(iconThemeSizes, width) {
+			return "", nil
+		}
+		return fmt.Sprintf("%dx%d", width, height), nil
+	}
+	return "", nil
+}
+
+func readPngDimensions(name string) (int, int, error) {
+	file, err := os.Open(name)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer file.Close()
+	header := make([]byte, 24)
+	if _, err = io.ReadFull(file, header); err != nil {
+		return 0, 0, err
+	}
+	if !bytes.HasPrefix(header, pngMagic) || string(header[12:16]) != "IHDR" {
+		return 0, 0, errors.New("invalid png header")
+	}
+	width := binary.BigEndian.Uint32(header[16:20])
+	height := binary.BigEndian.Uint32(header[20:24])
+	return int(width), int(height), nil
+}
+
+func ConstructThemeIconPath(iconsDir string, iconName string, icon ThemeIcon) string {
+	return path.Join(iconsDir, IconThemeName, icon.Size, "apps", fmt.Sprintf("%s.%s", iconName, icon.Format))
+}
+
+func InstallThemeIcons(paths *AppPaths, iconsDir string, iconName string, icons []ThemeIcon) error {
+	if err := UninstallThemeIcons(paths); err != nil {
+		return err
+	}
+	for _, x := range icons {
+		dest := ConstructThemeIconPath(iconsDir, iconName, x)
+		if err := os.MkdirAll(path.Dir(dest), utils.DirPermissions); err != nil {
+			return err
+		}
+		if err := utils.CopyFile(x.Path, dest); err != nil {
+			return err
+		}
+		paths.Icons = append(paths.Icons, dest)
+	}
+	return nil
+}
+
+func UninstallThemeIcons(paths *AppPaths) error {
+	for _, x := range paths.Icons {
+		if err := os.Remove(x); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+	}
+	paths.Icons = []string{}
+	return nil
+}
+
+func RenameThemeIcons(paths *AppPaths, iconName string) error {
+	icons := []string{}
+	for _, x := range paths.Icons {
+		dest := path.Join(path.Dir(x), iconName+path.Ext(x))
+		if err := os.Rename(x, dest); err != nil {
+			return err
+		}
+		icons = append(icons, dest)
+	}
+	paths.Icons = icons
+	return nil
+}
+
+// Returns the icon theme name if icons were installed into the theme,
+// otherwise the absolute path of the icon.
+func GetDesktopIconValue(paths *AppPaths) string {
+	if len(paths.Icons) == 0 {
+		return paths.Icon
+	}
+	name := path.Base(paths.Icons[0])
+	return strings.TrimSuffix(name, path.Ext(name))
+}
+
+func RefreshIconCache(iconsDir string) error {
+	themeDir := path.Join(iconsDir, IconThemeName)
+	if exists, err := utils.FileExists(themeDir); err != nil || !exists {
+		return err
+	}
+	if _, err := exec.LookPath("gtk-update-icon-cache"); err == nil {
+		cmd := exec.Command("gtk-update-icon-cache", "--force", "--ignore-theme-index", "--quiet", themeDir)
+		if err = cmd.Run(); err != nil {
+			return err
+		}
+	}
+	if _, err := exec.LookPath("xdg-icon-resource"); err == nil {
+		cmd := exec.Command("xdg-icon-resource", "forceupdate")
+		return cmd.Run()
+	}
+	return nil
+}
@@ -0,0 +1,65 @@
+package core
+
+import (
+	"fmt"
+
+	"github.com/zyrouge/pho/utils"
+)
+
+type AppLaunchProfile struct {
+	Env        map[string]string `json:"Env"`
+	Args       []string          `json:"Args"`
+	WorkingDir string            `json:"WorkingDir"`
+}
+
+type AppLaunch struct {
+	Exec string
+	Args []string
+	// KEY=VALUE pairs that are added on top of the inherited environment
+	Env []string
+	Dir string
+}
+
+type BuildAppLaunchOptions struct {
+	// the launching process waits for the application to exit
+	Foreground bool
+}
+
+// Builds the invocation shared by `run` and the generated .desktop file, so
+// that an application is launched the same way from everywhere.
+func BuildAppLaunch(app *AppConfig, options *BuildAppLaunchOptions) (*AppLaunch, error) {
+	profile := &app.Launch
+	launch := &AppLaunch{
+		Exec: GetAppExecPath(app),
+		Args: append([]string{}, profile.Args...),
+		Env:  []string{},
+		Dir:  profile.WorkingDir,
+	}
+	if app.Extracted {
+		launch.Env = append(launch.Env, buildAppRunEnv(app)...)
+	}
+	launch.Env = append(launch.Env, utils.FormatEnvMap(profile.Env)...)
+	if app.Sandbox.Enabled {
+		if err := WrapSandboxLaunch(launch, app, options.Foreground); err != nil {
+			return nil, err
+		}
+	}
+	return launch, nil
+}
+
+// Mimics the environment that the AppImage runtime provides to AppRun.
+func buildAppRunEnv(app *AppConfig) []string {
+	env := []string{
+		fmt.Sprintf("APPDIR=%s", GetAppDirPath(&app.Paths)),
+		fmt.Sprintf("APPIMAGE=%s", app.Paths.AppImage),
+		fmt.Sprintf("ARGV0=%s", app.Paths.AppImage),
+	}
+	if app.Portable {
+		env = append(
+			env,
+			fmt.Sprintf("HOME=%s", app.Paths.AppImage+".home"),
+			fmt.Sprintf("XDG_CONFIG_HOME=%s", GetPortableConfigDir(app.Paths.AppImage)),
+		)
+	}
+	return env
+}
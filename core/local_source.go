@@ -1,17 +1,13 @@
 package core
 
-import (
-	"errors"
-
-	"github.com/zyrouge/pho/utils"
-)
+import "errors"
 
 const LocalSourceId SourceId = "local"
 
 type LocalSource struct{}
 
 func ReadLocalSourceConfig(configPath string) (*LocalSource, error) {
-	return utils.ReadJsonFile[LocalSource](configPath)
+	return ReadVersionedJsonFile[LocalSource](configPath, SourceConfigSchema)
 }
 
 func (*LocalSource) SupportUpdates() bool {
@@ -0,0 +1,122 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/zyrouge/pho/utils"
+)
+
+var LockTimeout = 30 * time.Second
+
+const lockRetryInterval = time.Second / 4
+
+type stateLock struct {
+	file  *os.File
+	depth int
+}
+
+var stateLockMutex sync.Mutex
+var heldStateLock *stateLock
+
+func GetLockPath() (string, error) {
+	stateDir, err := GetStateDir()
+	if err != nil {
+		return "", err
+	}
+	lockPath := path.Join(stateDir, "pho.lock")
+	return lockPath, nil
+}
+
+// Serializes mutations of the config and transactions between concurrent
+// pho processes using an advisory lock. The lock is reentrant within a
+// process and the returned function releases it.
+func LockState() (func(), error) {
+	stateLockMutex.Lock()
+	defer stateLockMutex.Unlock()
+	if heldStateLock != nil {
+		heldStateLock.depth++
+		return unlockState, nil
+	}
+	if err := CheckSystemPermissions(); err != nil {
+		return nil, err
+	}
+	lockPath, err := GetLockPath()
+	if err != nil {
+		return nil, err
+	}
+	if err = os.MkdirAll(path.Dir(lockPath), utils.DirPermissions); err != nil {
+		return nil, err
+	}
+	file, err := os.OpenFile(lockPath, os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, err
+	}
+	deadline := time.Now().Add(LockTimeout)
+	waiting := false
+	for {
+		err = syscall.Flock(int(file.Fd()), syscall.LOCK_EX|syscall.LOCK_NB)
+		if err == nil {
+			break
+		}
+		if !errors.Is(err, syscall.EWOULDBLOCK) {
+			file.Close()
+			return nil, err
+		}
+		holder := describeLockHolder(file)
+		if time.Now().After(deadline) {
+			file.Close()
+			return nil, fmt.Errorf("%s, try again once it has finished", holder)
+		}
+		if !waiting {
+			utils.LogWarning(fmt.Sprintf("%s, waiting for up to %s", holder, LockTimeout))
+			waiting = true
+		}
+		time.Sleep(lockRetryInterval)
+	}
+	if err = file.Truncate(0); err == nil {
+		_, err = file.WriteAt([]byte(strconv.Itoa(os.Getpid())), 0)
+	}
+	if err != nil {
+		utils.LogDebug(fmt.Sprintf("unable to write pid to %s: %v", lockPath, err))
+	}
+	heldStateLock = &stateLock{file: file, depth: 1}
+	// another process may have changed the config before the lock was taken
+	cachedConfig = nil
+	return unlockState, nil
+}
+
+func unlockState() {
+	stateLockMutex.Lock()
+	defer stateLockMutex.Unlock()
+	if heldStateLock == nil {
+		return
+	}
+	heldStateLock.depth--
+	if heldStateLock.depth > 0 {
+		return
+	}
+	file := heldStateLock.file
+	heldStateLock = nil
+	file.Truncate(0)
+	syscall.Flock(int(file.Fd()), syscall.LOCK_UN)
+	file.Close()
+}
+
+func describeLockHolder(file *os.File) string {
+	content, err := io.ReadAll(io.NewSectionReader(file, 0, 32))
+	if err == nil {
+		if pid, err := strconv.Atoi(strings.TrimSpace(string(content))); err == nil {
+			return fmt.Sprintf("another %s process is running (pid %d)", AppExecutableName, pid)
+		}
+	}
+	return fmt.Sprintf("another %s process is running", AppExecutableName)
+}
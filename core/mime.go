@@ -0,0 +1,197 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path"
+	"strings"
+
+	"github.com/zyrouge/pho/utils"
+)
+
+// Reference: https://specifications.freedesktop.org/shared-mime-info-spec/latest/
+// Reference: https://specifications.freedesktop.org/mime-apps-spec/latest/
+
+const mimeAppsListFileName = "mimeapps.list"
+
+var mimeAppsListGroups = []string{
+	"Default Applications",
+	"Added Associations",
+}
+
+var errSystemMimeDefaults = errors.New("default applications can only be set per user, run without --system")
+
+func GetDefaultMimeDir() (string, error) {
+	if SystemMode {
+		return path.Join(SystemDataDir, "mime"), nil
+	}
+	dataDir, err := utils.GetXdgDataHome()
+	if err != nil {
+		return "", err
+	}
+	return path.Join(dataDir, "mime"), nil
+}
+
+func ConstructAppMimePackageName(appId string, name string) string {
+	return fmt.Sprintf("%s-%s-%s", GetSharedNamePrefix(), appId, name)
+}
+
+func ConstructAppMimePackagePath(mimeDir string, appId string, name string) string {
+	return path.Join(mimeDir, "packages", ConstructAppMimePackageName(appId, path.Base(name)))
+}
+
+// Collects shared-mime-info packages shipped at usr/share/mime/packages inside the AppDir.
+func FindMimePackages(appDir string) ([]string, error) {
+	packages := []string{}
+	packagesDir := path.Join(appDir, "usr/share/mime/packages")
+	files, err := os.ReadDir(packagesDir)
+	if errors.Is(err, os.ErrNotExist) {
+		return packages, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	for _, x := range files {
+		if x.IsDir() || !strings.HasSuffix(x.Name(), ".xml") {
+			continue
+		}
+		// symlinks are followed only within the app directory
+		name, err := utils.ResolvePathInDir(appDir, path.Join(packagesDir, x.Name()))
+		if err != nil {
+			continue
+		}
+		packages = append(packages, name)
+	}
+	return packages, nil
+}
+
+func InstallMimePackages(paths *AppPaths, mimeDir string, appId string, packages []string) error {
+	if err := UninstallMimePackages(paths); err != nil {
+		return err
+	}
+	if len(packages) == 0 {
+		return nil
+	}
+	packagesDir := path.Join(mimeDir, "packages")
+	if err := os.MkdirAll(packagesDir, utils.DirPermissions); err != nil {
+		return err
+	}
+	for _, x := range packages {
+		dest := ConstructAppMimePackagePath(mimeDir, appId, x)
+		if err := utils.CopyFile(x, dest); err != nil {
+			return err
+		}
+		paths.MimePackages = append(paths.MimePackages, dest)
+	}
+	return nil
+}
+
+func UninstallMimePackages(paths *AppPaths) error {
+	for _, x := range paths.MimePackages {
+		if err := os.Remove(x); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+	}
+	paths.MimePackages = []string{}
+	return nil
+}
+
+func RenameMimePackages(paths *AppPaths, fromAppId string, toAppId string) error {
+	packages := []string{}
+	fromPrefix := ConstructAppMimePackageName(fromAppId, "")
+	for _, x := range paths.MimePackages {
+		name := strings.TrimPrefix(path.Base(x), fromPrefix)
+		dest := path.Join(path.Dir(x), ConstructAppMimePackageName(toAppId, name))
+		if err := os.Rename(x, dest); err != nil {
+			return err
+		}
+		packages = append(packages, dest)
+	}
+	paths.MimePackages = packages
+	return nil
+}
+
+func UpdateMimeDatabase(mimeDir string) error {
+	if exists, err := utils.FileExists(path.Join(mimeDir, "packages")); err != nil || !exists {
+		return err
+	}
+	if _, err := exec.LookPath("update-mime-database"); err != nil {
+		return errors.New("update-mime-database is not available")
+	}
+	cmd := exec.Command("update-mime-database", mimeDir)
+	return cmd.Run()
+}
+
+func UpdateDesktopDatabase(desktopDir string) error {
+	if _, err := exec.LookPath("update-desktop-database"); err != nil {
+		return errors.New("update-desktop-database is not available")
+	}
+	cmd := exec.Command("update-desktop-database", desktopDir)
+	return cmd.Run()
+}
+
+func SetDefaultMimeHandler(desktopPath string, mimeType string) error {
+	if SystemMode {
+		return errSystemMimeDefaults
+	}
+	cmd := exec.Command("xdg-mime", "default", path.Base(desktopPath), mimeType)
+	return cmd.Run()
+}
+
+// Removes the .desktop file from every association in the user's mimeapps.list.
+func RemoveMimeAssociations(desktopPath string) error {
+	if SystemMode {
+		return nil
+	}
+	configDir, err := utils.GetXdgConfigHome()
+	if err != nil {
+		return err
+	}
+	listPath := path.Join(configDir, mimeAppsListFileName)
+	content, err := os.ReadFile(listPath)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	entry, err := ParseDesktopEntry(string(content))
+	if err != nil {
+		return err
+	}
+	desktopId := path.Base(desktopPath)
+	modified := false
+	for _, name := range mimeAppsListGroups {
+		group := entry.Group(name)
+		if group == nil {
+			continue
+		}
+		for _, line := range append([]*DesktopEntryLine{}, group.Lines...) {
+			if line.Key == "" {
+				continue
+			}
+			values, _ := group.GetList(line.Key)
+			remaining := []string{}
+			for _, x := range values {
+				if x != desktopId {
+					remaining = append(remaining, x)
+				}
+			}
+			if len(remaining) == len(values) {
+				continue
+			}
+			modified = true
+			if len(remaining) == 0 {
+				group.Delete(line.Key)
+			} else {
+				group.SetList(line.Key, remaining)
+			}
+		}
+	}
+	if !modified {
+		return nil
+	}
+	return utils.WriteFileAtomic(listPath, []byte(entry.String()))
+}
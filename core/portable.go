@@ -0,0 +1,166 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/zyrouge/pho/utils"
+)
+
+// Reference: https://docs.appimage.org/user-guide/portable-mode.html
+
+const portableDisabledSuffix = ".disabled"
+
+var portableDirSuffixes = []string{".home", ".config"}
+
+func GetPortableDirs(appImagePath string) []string {
+	dirs := []string{}
+	for _, x := range portableDirSuffixes {
+		dirs = append(dirs, appImagePath+x)
+	}
+	return dirs
+}
+
+func GetPortableConfigDir(appImagePath string) string {
+	return appImagePath + ".config"
+}
+
+// Restores previously disabled directories instead of creating new ones.
+func EnablePortableDirs(appImagePath string) error {
+	for _, x := range GetPortableDirs(appImagePath) {
+		disabled := x + portableDisabledSuffix
+		exists, err := utils.FileExists(disabled)
+		if err != nil {
+			return err
+		}
+		if exists {
+			if err = os.Rename(disabled, x); err != nil {
+				return err
+			}
+			continue
+		}
+		if err = os.MkdirAll(x, utils.DirPermissions); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// The runtime enables portable mode whenever the directories exist, so they
+// are moved aside to keep their data.
+func DisablePortableDirs(appImagePath string) error {
+	for _, x := range GetPortableDirs(appImagePath) {
+		exists, err := utils.FileExists(x)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			continue
+		}
+		disabled := x + portableDisabledSuffix
+		if exists, err = utils.FileExists(disabled); err != nil {
+			return err
+		}
+		if exists {
+			return fmt.Errorf("cannot disable %s as %s already exists", x, disabled)
+		}
+		if err = os.Rename(x, disabled); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func RenamePortableDirs(fromAppImagePath string, toAppImagePath string) error {
+	fromDirs := GetPortableDirs(fromAppImagePath)
+	toDirs := GetPortableDirs(toAppImagePath)
+	for i := range fromDirs {
+		for _, suffix := range []string{"", portableDisabledSuffix} {
+			from := fromDirs[i] + suffix
+			exists, err := utils.FileExists(from)
+			if err != nil {
+				return err
+			}
+			if !exists {
+				continue
+			}
+			if err = os.Rename(from, toDirs[i]+suffix); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Copies existing data of `~/.config/<name>` into the portable config directory.
+func MigratePortableConfig(appImagePath string, name string) error {
+	if name == "" || strings.Contains(name, "/") || name == "." || name == ".." {
+		return fmt.Errorf("invalid config directory name %s", name)
+	}
+	configDir, err := utils.GetXdgConfigHome()
+	if err != nil {
+		return err
+	}
+	src := path.Join(configDir, name)
+	dest := path.Join(GetPortableConfigDir(appImagePath), name)
+	if exists, err := utils.FileExists(dest); err != nil {
+		return err
+	} else if exists {
+		return fmt.Errorf("%s already exists", dest)
+	}
+	return utils.CopyDir(src, dest)
+}
+
+func IsPortableDataName(name string) bool {
+	for _, x := range portableDirSuffixes {
+		for _, suffix := range []string{"", portableDisabledSuffix} {
+			if strings.HasSuffix(name, ".AppImage"+x+suffix) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Removes the application directory, leaving the portable data behind unless purged.
+func RemoveAppDir(dir string, purge bool) error {
+	if purge {
+		return os.RemoveAll(dir)
+	}
+	files, err := os.ReadDir(dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	kept := 0
+	for _, x := range files {
+		if x.IsDir() && IsPortableDataName(x.Name()) {
+			kept++
+			continue
+		}
+		if err = os.RemoveAll(path.Join(dir, x.Name())); err != nil {
+			return err
+		}
+	}
+	if kept == 0 {
+		return os.Remove(dir)
+	}
+	return nil
+}
+
+func HasPortableData(appImagePath string) (bool, error) {
+	for _, x := range GetPortableDirs(appImagePath) {
+		for _, suffix := range []string{"", portableDisabledSuffix} {
+			exists, err := utils.FileExists(x + suffix)
+			if err != nil || exists {
+				return exists, err
+			}
+		}
+	}
+	return false, nil
+}
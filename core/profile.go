@@ -0,0 +1,96 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"sort"
+
+	"github.com/fatih/color"
+	"github.com/zyrouge/pho/utils"
+)
+
+// Installation profiles are separate sets of applications, each with its own
+// config and registry. Not to be confused with launch profiles of an app.
+const ProfileEnv = "PHO_PROFILE"
+const ProfilesDirName = "profiles"
+
+// profile passed with --profile
+var ProfileOverride = ""
+
+func GetProfile() string {
+	if ProfileOverride != "" {
+		return ProfileOverride
+	}
+	return os.Getenv(ProfileEnv)
+}
+
+func ValidateProfileName(name string) error {
+	if name == "" || name != utils.CleanId(name) {
+		return fmt.Errorf(
+			"invalid profile name %s, only lowercase letters, digits and dashes are allowed",
+			color.CyanString(name),
+		)
+	}
+	return nil
+}
+
+// Prefix of the names of files that are shared between profiles, such as
+// icons and .desktop files, so that applications with the same id do not
+// clash. Symlinks keep the id, so they are owned by a single profile.
+func GetSharedNamePrefix() string {
+	if profile := GetProfile(); profile != "" {
+		return fmt.Sprintf("%s-%s", AppCodeName, profile)
+	}
+	return AppCodeName
+}
+
+func GetProfilesDir() (string, error) {
+	configDir, err := GetConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return path.Join(configDir, ProfilesDirName), nil
+}
+
+func GetProfileConfigPath(name string) (string, error) {
+	if err := ValidateProfileName(name); err != nil {
+		return "", err
+	}
+	profilesDir, err := GetProfilesDir()
+	if err != nil {
+		return "", err
+	}
+	return path.Join(profilesDir, name, "config.json"), nil
+}
+
+// Returns the names of the initialized profiles.
+func ListProfiles() ([]string, error) {
+	profilesDir, err := GetProfilesDir()
+	if err != nil {
+		return nil, err
+	}
+	files, err := os.ReadDir(profilesDir)
+	if errors.Is(err, os.ErrNotExist) {
+		return []string{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	profiles := []string{}
+	for _, x := range files {
+		if !x.IsDir() || ValidateProfileName(x.Name()) != nil {
+			continue
+		}
+		exists, err := utils.FileExists(path.Join(profilesDir, x.Name(), "config.json"))
+		if err != nil {
+			return nil, err
+		}
+		if exists {
+			profiles = append(profiles, x.Name())
+		}
+	}
+	sort.Strings(profiles)
+	return profiles, nil
+}
@@ -0,0 +1,167 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path"
+
+	"github.com/zyrouge/pho/utils"
+)
+
+type SandboxBackend string
+
+const (
+	SandboxBackendBubblewrap SandboxBackend = "bwrap"
+	SandboxBackendFirejail   SandboxBackend = "firejail"
+)
+
+var SandboxBackends = []SandboxBackend{
+	SandboxBackendBubblewrap,
+	SandboxBackendFirejail,
+}
+
+type AppSandboxPolicy struct {
+	Enabled bool `json:"Enabled"`
+	// empty picks the first available backend
+	Backend SandboxBackend `json:"Backend"`
+	// paths that are accessible (read-write) inside the sandbox
+	Filesystem []string `json:"Filesystem"`
+	Network    bool     `json:"Network"`
+	Devices    bool     `json:"Devices"`
+}
+
+func ParseSandboxBackend(value string) (SandboxBackend, error) {
+	if value == "" {
+		return "", nil
+	}
+	for _, x := range SandboxBackends {
+		if string(x) == value {
+			return x, nil
+		}
+	}
+	return "", fmt.Errorf("invalid sandbox backend %s", value)
+}
+
+func FindSandboxBackend(preferred SandboxBackend) (SandboxBackend, string, error) {
+	if preferred != "" {
+		execPath, err := exec.LookPath(string(preferred))
+		if err != nil {
+			return "", "", fmt.Errorf("sandbox backend %s is not installed", preferred)
+		}
+		return preferred, execPath, nil
+	}
+	for _, x := range SandboxBackends {
+		if execPath, err := exec.LookPath(string(x)); err == nil {
+			return x, execPath, nil
+		}
+	}
+	return "", "", errors.New("sandbox is enabled but neither bwrap nor firejail is installed")
+}
+
+// Wraps the launch in the sandbox backend. The environment of the launch is
+// inherited by the backend, so only the executable and arguments change.
+func WrapSandboxLaunch(launch *AppLaunch, app *AppConfig, foreground bool) error {
+	policy := &app.Sandbox
+	backend, execPath, err := FindSandboxBackend(policy.Backend)
+	if err != nil {
+		return err
+	}
+	args := []string{}
+	switch backend {
+	case SandboxBackendBubblewrap:
+		args = buildBubblewrapArgs(app, foreground)
+	case SandboxBackendFirejail:
+		args = buildFirejailArgs(app)
+	}
+	args = append(args, launch.Exec)
+	launch.Args = append(args, launch.Args...)
+	launch.Exec = execPath
+	return nil
+}
+
+func buildBubblewrapArgs(app *AppConfig, foreground bool) []string {
+	policy := &app.Sandbox
+	args := []string{
+		"--ro-bind", "/", "/",
+		"--proc", "/proc",
+	}
+	if policy.Devices {
+		args = append(args, "--dev-bind", "/dev", "/dev")
+	} else {
+		args = append(args, "--dev", "/dev")
+	}
+	args = append(
+		args,
+		"--tmpfs", "/tmp",
+		"--ro-bind-try", "/tmp/.X11-unix", "/tmp/.X11-unix",
+	)
+	if home, err := os.UserHomeDir(); err == nil {
+		args = append(args, "--tmpfs", home)
+	}
+	if xauthority := os.Getenv("XAUTHORITY"); xauthority != "" {
+		args = append(args, "--ro-bind-try", xauthority, xauthority)
+	}
+	args = append(args, "--ro-bind", app.Paths.Dir, app.Paths.Dir)
+	if app.Portable {
+		for _, x := range GetPortableDirs(app.Paths.AppImage) {
+			args = append(args, "--bind-try", x, x)
+		}
+	}
+	for _, x := range policy.Filesystem {
+		args = append(args, "--bind-try", x, x)
+	}
+	args = append(args, "--unshare-all")
+	if policy.Network {
+		args = append(args, "--share-net")
+	}
+	// detached processes and .desktop launchers exit right away, which
+	// would take the sandbox down with them
+	if foreground {
+		args = append(args, "--die-with-parent")
+	}
+	// FUSE is unavailable inside the sandbox
+	return append(
+		args,
+		"--setenv", "APPIMAGE_EXTRACT_AND_RUN", "1",
+		"--",
+	)
+}
+
+func buildFirejailArgs(app *AppConfig) []string {
+	policy := &app.Sandbox
+	args := []string{"--quiet"}
+	if !app.Extracted {
+		args = append(args, "--appimage")
+	}
+	if !policy.Network {
+		args = append(args, "--net=none")
+	}
+	if !policy.Devices {
+		args = append(args, "--private-dev", "--no3d", "--novideo")
+	}
+	allowed := append([]string{}, policy.Filesystem...)
+	if app.Portable {
+		allowed = append(allowed, GetPortableDirs(app.Paths.AppImage)...)
+	}
+	if len(allowed) == 0 {
+		args = append(args, "--private")
+	}
+	for _, x := range allowed {
+		args = append(args, fmt.Sprintf("--whitelist=%s", path.Clean(x)))
+	}
+	return append(args, "--")
+}
+
+func ResolveSandboxPaths(paths []string) ([]string, error) {
+	resolved := []string{}
+	for _, x := range paths {
+		p, err := utils.ResolvePath(x)
+		if err != nil {
+			return nil, err
+		}
+		resolved = append(resolved, p)
+	}
+	return resolved, nil
+}
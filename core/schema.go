@@ -0,0 +1,228 @@
+package core
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"syscall"
+
+	"github.com/zyrouge/pho/utils"
+)
+
+const SchemaVersionKey = "SchemaVersion"
+
+// Upgrades the raw JSON object of a file by one schema version.
+type SchemaMigration func(data map[string]any) error
+
+// Every config file records the version of its schema. Older files are
+// migrated when read, while newer ones are refused since they may contain
+// fields that would be lost.
+type Schema struct {
+	Name string
+	// the migration at index i upgrades version i to i+1
+	Migrations []SchemaMigration
+}
+
+func (schema *Schema) Version() int {
+	return len(schema.Migrations)
+}
+
+var ConfigSchema = &Schema{
+	Name: "config",
+	Migrations: []SchemaMigration{
+		migrateConfigV1,
+	},
+}
+
+var AppConfigSchema = &Schema{
+	Name: "app config",
+	Migrations: []SchemaMigration{
+		migrateAppConfigV1,
+	},
+}
+
+var SourceConfigSchema = &Schema{
+	Name: "source config",
+	Migrations: []SchemaMigration{
+		migrateSourceConfigV1,
+	},
+}
+
+func ReadVersionedJsonFile[T any](name string, schema *Schema) (*T, error) {
+	data, version, err := readVersionedJsonObject(name, schema)
+	if err != nil {
+		return nil, err
+	}
+	if version < schema.Version() {
+		if canPersistMigration(name) {
+			data, err = migrateJsonFile(name, schema)
+		} else {
+			utils.LogDebug(fmt.Sprintf("migrating %s in memory", name))
+			err = migrateJsonObject(data, schema, version)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("unable to migrate %s at %s: %v", schema.Name, name, err)
+		}
+	}
+	migrated, err := json.Marshal(data)
+	if err != nil {
+		return nil, err
+	}
+	var result T
+	if err = json.Unmarshal(migrated, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+func WriteVersionedJsonFile(name string, value any, schema *Schema) error {
+	content, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	data := map[string]any{}
+	if err = json.Unmarshal(content, &data); err != nil {
+		return err
+	}
+	data[SchemaVersionKey] = schema.Version()
+	return writeJsonObject(name, data)
+}
+
+func readVersionedJsonObject(name string, schema *Schema) (map[string]any, int, error) {
+	content, err := os.ReadFile(name)
+	if err != nil {
+		return nil, 0, err
+	}
+	data := map[string]any{}
+	if err = json.Unmarshal(content, &data); err != nil {
+		return nil, 0, err
+	}
+	version, err := getSchemaVersion(data)
+	if err != nil {
+		return nil, 0, fmt.Errorf("invalid %s at %s: %v", schema.Name, name, err)
+	}
+	if version > schema.Version() {
+		return nil, 0, fmt.Errorf(
+			"%s at %s has schema version %d but only up to %d is supported, update %s",
+			schema.Name,
+			name,
+			version,
+			schema.Version(),
+			AppExecutableName,
+		)
+	}
+	return data, version, nil
+}
+
+// Kept versions are snapshots and read-only users, such as the ones of a
+// system installation, cannot write, so both are migrated in memory.
+func canPersistMigration(name string) bool {
+	if path.Base(path.Dir(path.Dir(name))) == AppVersionsDirName {
+		return false
+	}
+	if CheckSystemPermissions() != nil || !utils.IsDirWritable(path.Dir(name)) {
+		return false
+	}
+	// W_OK
+	return syscall.Access(name, 0x2) == nil
+}
+
+// The file is read again while holding the lock, so that concurrent
+// migrations do not overwrite each other. The original file is kept next
+// to the migrated one.
+func migrateJsonFile(name string, schema *Schema) (map[string]any, error) {
+	unlock, err := LockState()
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+	content, err := os.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+	data, version, err := readVersionedJsonObject(name, schema)
+	if err != nil || version == schema.Version() {
+		return data, err
+	}
+	backupPath := fmt.Sprintf("%s.v%d.bak", name, version)
+	utils.LogDebug(fmt.Sprintf("backing up %s to %s", name, backupPath))
+	if err = os.WriteFile(backupPath, content, utils.FilePermissions); err != nil {
+		return nil, err
+	}
+	utils.LogDebug(fmt.Sprintf("migrating %s", name))
+	if err = migrateJsonObject(data, schema, version); err != nil {
+		return nil, err
+	}
+	return data, writeJsonObject(name, data)
+}
+
+func migrateJsonObject(data map[string]any, schema *Schema, version int) error {
+	for i := version; i < schema.Version(); i++ {
+		if err := schema.Migrations[i](data); err != nil {
+			return err
+		}
+	}
+	data[SchemaVersionKey] = schema.Version()
+	return nil
+}
+
+func writeJsonObject(name string, data map[string]any) error {
+	content, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	return utils.WriteFileAtomic(name, content)
+}
+
+// Files written before schema versioning have no version.
+func getSchemaVersion(data map[string]any) (int, error) {
+	value, ok := data[SchemaVersionKey]
+	if !ok || value == nil {
+		return 0, nil
+	}
+	version, ok := value.(float64)
+	if !ok || version < 0 || version != float64(int(version)) {
+		return 0, fmt.Errorf("invalid schema version %v", value)
+	}
+	return int(version), nil
+}
+
+func setDefaultJsonValue(data map[string]any, key string, value any) {
+	if current, ok := data[key]; !ok || current == nil || current == "" {
+		data[key] = value
+	}
+}
+
+// Fills the fields introduced before schema versioning.
+func migrateConfigV1(data map[string]any) error {
+	iconsDir, err := GetDefaultIconsDir()
+	if err != nil {
+		return err
+	}
+	mimeDir, err := GetDefaultMimeDir()
+	if err != nil {
+		return err
+	}
+	setDefaultJsonValue(data, "IconsDir", iconsDir)
+	setDefaultJsonValue(data, "MimeDir", mimeDir)
+	setDefaultJsonValue(data, "SignaturePolicy", string(SignaturePolicyWarn))
+	setDefaultJsonValue(data, "KeepVersions", DefaultKeepVersions)
+	setDefaultJsonValue(data, "Installed", map[string]any{})
+	return nil
+}
+
+func migrateAppConfigV1(data map[string]any) error {
+	setDefaultJsonValue(data, "BaseId", data["Id"])
+	if paths, ok := data["Paths"].(map[string]any); ok {
+		if dir, ok := paths["Dir"].(string); ok {
+			setDefaultJsonValue(paths, "AppDir", path.Join(dir, AppDirName))
+		}
+	}
+	return nil
+}
+
+// Source configs are unchanged, only the version is recorded.
+func migrateSourceConfigV1(data map[string]any) error {
+	return nil
+}
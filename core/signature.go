@@ -0,0 +1,150 @@
+package core
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"debug/elf"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+)
+
+type SignaturePolicy string
+
+const (
+	SignaturePolicyIgnore  SignaturePolicy = "ignore"
+	SignaturePolicyWarn    SignaturePolicy = "warn"
+	SignaturePolicyRequire SignaturePolicy = "require"
+)
+
+var SignaturePolicies = []SignaturePolicy{
+	SignaturePolicyIgnore,
+	SignaturePolicyWarn,
+	SignaturePolicyRequire,
+}
+
+func ParseSignaturePolicy(value string) (SignaturePolicy, error) {
+	for _, x := range SignaturePolicies {
+		if string(x) == value {
+			return x, nil
+		}
+	}
+	return "", fmt.Errorf("invalid signature policy %s", value)
+}
+
+var ErrAppImageUnsigned = errors.New("appimage is not signed")
+
+const (
+	appImageSignatureSection = ".sha256_sig"
+	appImageSigKeySection    = ".sig_key"
+)
+
+type appImageSection struct {
+	Offset int64
+	Size   int64
+	Data   []byte
+}
+
+// Reference: https://github.com/AppImage/AppImageSpec/blob/master/draft.md#signing
+// The signature is an armored detached OpenPGP signature of the hex encoded
+// SHA-256 digest of the AppImage, hashed with the signature and key sections
+// zeroed.
+func VerifyAppImageSignature(appImagePath string) (string, error) {
+	signature, key, err := readAppImageSignatureSections(appImagePath)
+	if err != nil {
+		return "", err
+	}
+	if len(signature.Data) == 0 {
+		return "", ErrAppImageUnsigned
+	}
+	if len(key.Data) == 0 {
+		return "", errors.New("appimage signature has no embedded public key")
+	}
+	digest, err := hashAppImage(appImagePath, []*appImageSection{signature, key})
+	if err != nil {
+		return "", err
+	}
+	keyring, err := openpgp.ReadArmoredKeyRing(bytes.NewReader(key.Data))
+	if err != nil {
+		return "", fmt.Errorf("invalid appimage public key: %v", err)
+	}
+	var signer *openpgp.Entity
+	// older versions of appimagetool signed the digest followed by a newline
+	for _, x := range []string{digest, digest + "\n"} {
+		signer, err = openpgp.CheckArmoredDetachedSignature(
+			keyring,
+			strings.NewReader(x),
+			bytes.NewReader(signature.Data),
+			nil,
+		)
+		if err == nil {
+			break
+		}
+	}
+	if err != nil {
+		return "", fmt.Errorf("invalid appimage signature: %v", err)
+	}
+	return strings.ToUpper(hex.EncodeToString(signer.PrimaryKey.Fingerprint)), nil
+}
+
+func readAppImageSignatureSections(appImagePath string) (*appImageSection, *appImageSection, error) {
+	file, err := elf.Open(appImagePath)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer file.Close()
+	sections := []*appImageSection{}
+	for _, name := range []string{appImageSignatureSection, appImageSigKeySection} {
+		section := &appImageSection{}
+		if x := file.Section(name); x != nil {
+			data, err := x.Data()
+			if err != nil {
+				return nil, nil, err
+			}
+			section.Offset = int64(x.Offset)
+			section.Size = int64(x.Size)
+			// sections are reserved with a fixed size and padded with zeros
+			section.Data = bytes.TrimRight(data, "\x00")
+		}
+		sections = append(sections, section)
+	}
+	return sections[0], sections[1], nil
+}
+
+func hashAppImage(appImagePath string, zeroed []*appImageSection) (string, error) {
+	file, err := os.Open(appImagePath)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+	sort.Slice(zeroed, func(i, j int) bool {
+		return zeroed[i].Offset < zeroed[j].Offset
+	})
+	hash := sha256.New()
+	position := int64(0)
+	for _, x := range zeroed {
+		if x.Size == 0 || x.Offset < position {
+			continue
+		}
+		if _, err = io.CopyN(hash, file, x.Offset-position); err != nil {
+			return "", err
+		}
+		if _, err = hash.Write(make([]byte, x.Size)); err != nil {
+			return "", err
+		}
+		if _, err = file.Seek(x.Size, io.SeekCurrent); err != nil {
+			return "", err
+		}
+		position = x.Offset + x.Size
+	}
+	if _, err = io.Copy(hash, file); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
@@ -0,0 +1,87 @@
+package core
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+
+	"github.com/zyrouge/pho/utils"
+)
+
+const StateDirEnv = "PHO_STATE_DIR"
+
+// Volatile files such as transactions and the lock are kept out of the
+// config directory. Custom configs and profiles get their own state, so
+// that separate instances do not share it.
+func GetStateDir() (string, error) {
+	stateDir, err := getBaseStateDir()
+	if err != nil {
+		return "", err
+	}
+	if profile := GetProfile(); profile != "" {
+		if err = ValidateProfileName(profile); err != nil {
+			return "", err
+		}
+		return path.Join(stateDir, ProfilesDirName, profile), nil
+	}
+	return stateDir, nil
+}
+
+func getBaseStateDir() (string, error) {
+	if dir := os.Getenv(StateDirEnv); dir != "" {
+		return utils.ResolvePath(dir)
+	}
+	if SystemMode {
+		return SystemStateDir, nil
+	}
+	xdgStateDir, err := utils.GetXdgStateHome()
+	if err != nil {
+		return "", err
+	}
+	stateDir := path.Join(xdgStateDir, AppCodeName)
+	if !IsDefaultConfigPath() {
+		configPath, err := getBaseConfigPath()
+		if err != nil {
+			return "", err
+		}
+		hash := sha256.Sum256([]byte(configPath))
+		return path.Join(stateDir, "configs", hex.EncodeToString(hash[:8])), nil
+	}
+	return stateDir, nil
+}
+
+// Moves a state file from the config directory, where it was kept by older
+// versions.
+func migrateLegacyStateFile(name string) error {
+	if !IsDefaultConfigPath() || GetProfile() != "" {
+		return nil
+	}
+	configDir, err := GetConfigDir()
+	if err != nil {
+		return err
+	}
+	legacyPath := path.Join(configDir, path.Base(name))
+	if legacyPath == name {
+		return nil
+	}
+	if _, err = os.Stat(legacyPath); errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if _, err = os.Stat(name); err == nil {
+		return nil
+	}
+	utils.LogDebug(fmt.Sprintf("moving %s to %s", legacyPath, name))
+	if err = os.MkdirAll(path.Dir(name), utils.DirPermissions); err != nil {
+		return err
+	}
+	if err = os.Rename(legacyPath, name); err == nil {
+		return nil
+	}
+	if err = utils.CopyFile(legacyPath, name); err != nil {
+		return err
+	}
+	return os.Remove(legacyPath)
+}
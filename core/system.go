@@ -0,0 +1,84 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"syscall"
+)
+
+const SystemConfigDir = "/etc/pho"
+const SystemStateDir = "/var/lib/pho"
+const SystemAppsDir = "/opt/pho"
+const SystemDataDir = "/usr/local/share"
+const SystemSymlinksDir = "/usr/local/bin"
+
+// Installs applications for every user, managed by root and with a registry
+// separate from the per-user one.
+var SystemMode = false
+
+func EnableSystemMode() {
+	SystemMode = true
+	// installed files must be readable by every user regardless of the
+	// umask of root
+	syscall.Umask(0022)
+}
+
+func CheckSystemPermissions() error {
+	if SystemMode && os.Geteuid() != 0 {
+		return errors.New("system installations can only be modified by root, try again with sudo")
+	}
+	return nil
+}
+
+// Mode passed to xdg-utils.
+func GetXdgUtilsMode() string {
+	if SystemMode {
+		return "system"
+	}
+	return "user"
+}
+
+// Profiles get their own directory, so that applications with the same id
+// do not clash.
+func GetDefaultAppsDir() (string, error) {
+	profile := GetProfile()
+	if SystemMode {
+		if profile != "" {
+			return fmt.Sprintf("%s-%s", SystemAppsDir, profile), nil
+		}
+		return SystemAppsDir, nil
+	}
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	dataDir := path.Join(homeDir, ".local/share", AppCodeName)
+	if profile != "" {
+		dataDir = path.Join(dataDir, ProfilesDirName, profile)
+	}
+	return path.Join(dataDir, "applications"), nil
+}
+
+func GetDefaultDesktopDir() (string, error) {
+	if SystemMode {
+		return path.Join(SystemDataDir, "applications"), nil
+	}
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return path.Join(homeDir, ".local/share", "applications"), nil
+}
+
+func GetDefaultSymlinksDir() (string, error) {
+	if SystemMode {
+		return SystemSymlinksDir, nil
+	}
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return path.Join(homeDir, ".local/bin"), nil
+}
@@ -11,6 +11,46 @@ import (
 type PendingInstallation struct {
 	InvolvedDirs  []string
 	InvolvedFiles []string
+	// files that existed before the installation, never removed
+	PreservedFiles []string
+	// previous files of an application that is being updated
+	Backup *AppBackup
+}
+
+// Adds the files that may be created by the installation, except the
+// preserved ones.
+func (pending *PendingInstallation) AddInvolvedFiles(files []string) {
+	for _, x := range files {
+		if !utils.SliceContains(pending.PreservedFiles, x) && !utils.SliceContains(pending.InvolvedFiles, x) {
+			pending.InvolvedFiles = append(pending.InvolvedFiles, x)
+		}
+	}
+}
+
+// Removes the files of an incomplete installation and restores the
+// previous version of the application, if any.
+func (pending *PendingInstallation) Rollback() error {
+	for _, x := range pending.InvolvedFiles {
+		if err := os.RemoveAll(x); err != nil {
+			return err
+		}
+	}
+	for _, x := range pending.InvolvedDirs {
+		if err := RemoveAppDir(x, false); err != nil {
+			return err
+		}
+	}
+	if pending.Backup != nil {
+		return pending.Backup.Restore()
+	}
+	return nil
+}
+
+func (pending *PendingInstallation) Commit() error {
+	if pending.Backup != nil {
+		return pending.Backup.Discard()
+	}
+	return nil
 }
 
 type Transactions struct {
@@ -18,11 +58,14 @@ type Transactions struct {
 }
 
 func GetTransactionsPath() (string, error) {
-	xdgConfigDir, err := os.UserConfigDir()
+	stateDir, err := GetStateDir()
 	if err != nil {
 		return "", err
 	}
-	transtionsPath := path.Join(xdgConfigDir, AppCodeName, "transactions.json")
+	transtionsPath := path.Join(stateDir, "transactions.json")
+	if err = migrateLegacyStateFile(transtionsPath); err != nil {
+		return "", err
+	}
 	return transtionsPath, nil
 }
 
@@ -46,6 +89,9 @@ func SaveTransactions(transactions *Transactions) error {
 	if err != nil {
 		return err
 	}
+	if err = os.MkdirAll(path.Dir(transtionsPath), utils.DirPermissions); err != nil {
+		return err
+	}
 	err = utils.WriteJsonFileAtomic[Transactions](transtionsPath, transactions)
 	if err != nil {
 		return err
@@ -56,6 +102,11 @@ func SaveTransactions(transactions *Transactions) error {
 type UpdateTransactionFunc func(transactions *Transactions) error
 
 func UpdateTransactions(performer UpdateTransactionFunc) error {
+	unlock, err := LockState()
+	if err != nil {
+		return err
+	}
+	defer unlock()
 	transactions, err := GetTransactions()
 	if err != nil {
 		return err
@@ -0,0 +1,145 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/zyrouge/pho/utils"
+)
+
+const AppVersionsDirName = "versions"
+const DefaultKeepVersions = 2
+
+// Previous version of an application, kept for rollbacks.
+type KeptAppVersion struct {
+	Version string
+	SavedAt int64
+	Dir     string
+	// snapshot of the app config at the time it was replaced
+	Config       string
+	SourceConfig string
+	AppImage     string
+}
+
+func GetAppVersionsDir(paths *AppPaths) string {
+	return path.Join(paths.Dir, AppVersionsDirName)
+}
+
+// Returns the kept versions of an application, latest first.
+func ListAppVersions(paths *AppPaths) ([]KeptAppVersion, error) {
+	versionsDir := GetAppVersionsDir(paths)
+	files, err := os.ReadDir(versionsDir)
+	if errors.Is(err, os.ErrNotExist) {
+		return []KeptAppVersion{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	versions := []KeptAppVersion{}
+	for _, x := range files {
+		if !x.IsDir() {
+			continue
+		}
+		version, err := readAppVersion(path.Join(versionsDir, x.Name()))
+		if err != nil {
+			utils.LogDebug(fmt.Sprintf("skipping broken version %s: %v", x.Name(), err))
+			continue
+		}
+		versions = append(versions, *version)
+	}
+	sort.SliceStable(versions, func(i, j int) bool {
+		return versions[i].SavedAt > versions[j].SavedAt
+	})
+	return versions, nil
+}
+
+func readAppVersion(dir string) (*KeptAppVersion, error) {
+	var savedAt int64
+	if _, err := fmt.Sscanf(path.Base(dir), "%d-", &savedAt); err != nil {
+		return nil, err
+	}
+	version := &KeptAppVersion{
+		SavedAt:      savedAt,
+		Dir:          dir,
+		Config:       path.Join(dir, "config.pho.json"),
+		SourceConfig: path.Join(dir, "source.pho.json"),
+	}
+	app, err := ReadAppConfig(version.Config)
+	if err != nil {
+		return nil, err
+	}
+	version.Version = app.Version
+	version.AppImage = path.Join(dir, path.Base(app.Paths.AppImage))
+	if _, err = os.Stat(version.AppImage); err != nil {
+		return nil, err
+	}
+	return version, nil
+}
+
+func FindAppVersion(versions []KeptAppVersion, version string) *KeptAppVersion {
+	for i := range versions {
+		if versions[i].Version == version {
+			return &versions[i]
+		}
+	}
+	return nil
+}
+
+// Moves the backed up AppImage and configs of the replaced version into the
+// versions directory, only the latest count versions are kept.
+func KeepAppVersion(app *AppConfig, backup *AppBackup, count int) error {
+	if count <= 0 {
+		return PruneAppVersions(&app.Paths, count)
+	}
+	previousConfig, ok := backup.Files[app.Paths.Config]
+	if !ok {
+		return nil
+	}
+	previous, err := ReadAppConfig(previousConfig)
+	if err != nil {
+		return err
+	}
+	previousAppImage, ok := backup.Files[previous.Paths.AppImage]
+	if !ok || previous.Sha256 != "" && previous.Sha256 == app.Sha256 {
+		return nil
+	}
+	dir := path.Join(
+		GetAppVersionsDir(&app.Paths),
+		fmt.Sprintf("%d-%s", time.Now().UnixNano(), strings.Trim(utils.CleanId(previous.Version), "-")),
+	)
+	if err = os.MkdirAll(dir, utils.DirPermissions); err != nil {
+		return err
+	}
+	moves := map[string]string{
+		previousAppImage: path.Join(dir, path.Base(previous.Paths.AppImage)),
+		previousConfig:   path.Join(dir, "config.pho.json"),
+	}
+	if previousSourceConfig, ok := backup.Files[previous.Paths.SourceConfig]; ok {
+		moves[previousSourceConfig] = path.Join(dir, "source.pho.json")
+	}
+	for src, dest := range moves {
+		if err = os.Rename(src, dest); err != nil {
+			os.RemoveAll(dir)
+			return err
+		}
+	}
+	return PruneAppVersions(&app.Paths, count)
+}
+
+func PruneAppVersions(paths *AppPaths, count int) error {
+	versions, err := ListAppVersions(paths)
+	if err != nil {
+		return err
+	}
+	for i := max(count, 0); i < len(versions); i++ {
+		if err = os.RemoveAll(versions[i].Dir); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -3,11 +3,14 @@ module github.com/zyrouge/pho
 go 1.21.1
 
 require (
+	github.com/ProtonMail/go-crypto v1.1.3
 	github.com/fatih/color v1.17.0
 	github.com/urfave/cli/v3 v3.0.0-alpha9
+	golang.org/x/crypto v0.17.0
 )
 
 require (
+	github.com/cloudflare/circl v1.3.7 // indirect
 	github.com/mattn/go-colorable v0.1.13 // indirect
 	github.com/mattn/go-isatty v0.0.20 // indirect
 	github.com/xrash/smetrics v0.0.0-20240312152122-5f08fbb34913 // indirect
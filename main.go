@@ -3,6 +3,7 @@ package main
 import (
 	"context"
 	"os"
+	"time"
 
 	"github.com/urfave/cli/v3"
 	"github.com/zyrouge/pho/commands"
@@ -24,6 +25,65 @@ func main() {
 					return nil
 				},
 			},
+			&cli.StringFlag{
+				Name:       "config",
+				Usage:      "Path to the config file, defaults to $PHO_CONFIG_DIR/config.json",
+				Persistent: true,
+				Action: func(_ context.Context, _ *cli.Command, value string) error {
+					configPath, err := utils.ResolvePath(value)
+					if err != nil {
+						return err
+					}
+					core.ConfigPathOverride = configPath
+					return nil
+				},
+			},
+			&cli.StringFlag{
+				Name:       "profile",
+				Usage:      "Installation profile to use, defaults to $PHO_PROFILE",
+				Persistent: true,
+				Action: func(_ context.Context, _ *cli.Command, value string) error {
+					if err := core.ValidateProfileName(value); err != nil {
+						return err
+					}
+					core.ProfileOverride = value
+					return nil
+				},
+			},
+			&cli.BoolFlag{
+				Name:       "system",
+				Usage:      "Manage applications installed for all users",
+				Persistent: true,
+				Action: func(_ context.Context, _ *cli.Command, b bool) error {
+					if b {
+						core.EnableSystemMode()
+					}
+					return nil
+				},
+			},
+			&cli.StringFlag{
+				Name:       "arch",
+				Usage:      "Target architecture of AppImages (amd64, 386, arm64, arm)",
+				Persistent: true,
+				Action: func(_ context.Context, _ *cli.Command, value string) error {
+					arch, err := core.ParseArch(value)
+					if err != nil {
+						return err
+					}
+					core.ArchOverride = arch
+					return nil
+				},
+			},
+			&cli.DurationFlag{
+				Name:       "lock-timeout",
+				Usage:      "How long to wait for other pho processes to finish",
+				Value:      core.LockTimeout,
+				Persistent: true,
+				Action: func(_ context.Context, _ *cli.Command, value time.Duration) error {
+					core.LockTimeout = value
+					return nil
+				},
+			},
 		},
 		Authors: []any{"Zyrouge"},
 		Commands: []*cli.Command{
@@ -31,10 +91,13 @@ func main() {
 			&commands.InstallCommand,
 			&commands.UninstallCommand,
 			&commands.UpdateCommand,
+			&commands.RollbackCommand,
 			&commands.RunCommand,
 			&commands.ListCommand,
+			&commands.ProfilesCommand,
 			&commands.ViewCommand,
 			&commands.TidyBrokenCommand,
+			&commands.DoctorCommand,
 			&commands.SelfUpdateCommand,
 			&commands.AppConfigCommand,
 		},
@@ -3,13 +3,19 @@ package utils
 import (
 	"encoding/json"
 	"fmt"
+	"io"
+	"io/fs"
 	"os"
 	"path"
+	"path/filepath"
 	"strings"
 )
 
 var AtomicFilePrefix = "pho"
 
+const DirPermissions os.FileMode = 0755
+const FilePermissions os.FileMode = 0644
+
 func CreateTempFile(name string) (*os.File, error) {
 	dir := path.Dir(name)
 	ext := path.Ext(name)
@@ -39,6 +45,10 @@ func WriteFileAtomic(name string, bytes []byte) error {
 			os.Remove(tempName)
 		}
 	}()
+	// temporary files are only readable by the owner
+	if err = temp.Chmod(FilePermissions); err != nil {
+		return err
+	}
 	if _, err = temp.Write(bytes); err != nil {
 		return err
 	}
@@ -76,7 +86,7 @@ func WriteJsonFile[T any](name string, data *T) error {
 	if err != nil {
 		return err
 	}
-	return os.WriteFile(name, json, os.ModePerm)
+	return os.WriteFile(name, json, FilePermissions)
 }
 
 func WriteJsonFileAtomic[T any](name string, data *T) error {
@@ -87,6 +97,50 @@ func WriteJsonFileAtomic[T any](name string, data *T) error {
 	return WriteFileAtomic(name, json)
 }
 
+// Copies the file, a symlink is recreated as is instead of being followed.
+func CopyFile(src string, dest string) error {
+	info, err := os.Lstat(src)
+	if err != nil {
+		return err
+	}
+	if info.Mode()&os.ModeSymlink != 0 {
+		link, err := os.Readlink(src)
+		if err != nil {
+			return err
+		}
+		return os.Symlink(link, dest)
+	}
+	srcFile, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer srcFile.Close()
+	destFile, err := os.Create(dest)
+	if err != nil {
+		return err
+	}
+	defer destFile.Close()
+	_, err = io.Copy(destFile, srcFile)
+	return err
+}
+
+// Resolves the symlinks of a path inside the directory, errors when the
+// path points outside of it.
+func ResolvePathInDir(dir string, name string) (string, error) {
+	resolvedDir, err := filepath.EvalSymlinks(dir)
+	if err != nil {
+		return "", err
+	}
+	resolved, err := filepath.EvalSymlinks(name)
+	if err != nil {
+		return "", err
+	}
+	if !strings.HasPrefix(resolved, resolvedDir+string(filepath.Separator)) {
+		return "", fmt.Errorf("%s points outside of %s", name, dir)
+	}
+	return resolved, nil
+}
+
 func FindFileInDir(dir string, names []string) (bool, string) {
 	for _, x := range names {
 		p := path.Join(dir, x)
@@ -96,3 +150,39 @@ func FindFileInDir(dir string, names []string) (bool, string) {
 	}
 	return false, ""
 }
+
+// Copies the directory tree recursively, symlinks are recreated as is.
+func CopyDir(src string, dest string) error {
+	return filepath.WalkDir(src, func(name string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		relative, err := filepath.Rel(src, name)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dest, relative)
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+		switch {
+		case entry.IsDir():
+			return os.MkdirAll(target, info.Mode().Perm()|0700)
+
+		case info.Mode()&os.ModeSymlink != 0:
+			link, err := os.Readlink(name)
+			if err != nil {
+				return err
+			}
+			return os.Symlink(link, target)
+
+		case info.Mode().IsRegular():
+			if err := CopyFile(name, target); err != nil {
+				return err
+			}
+			return os.Chmod(target, info.Mode().Perm())
+		}
+		return nil
+	})
+}
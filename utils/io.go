@@ -6,6 +6,7 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"syscall"
 )
 
 func ReaderReadLine(reader *bufio.Reader) (string, error) {
@@ -27,6 +28,22 @@ func FileExists(name string) (bool, error) {
 	return false, err
 }
 
+// Checks the nearest existing ancestor when the directory does not exist yet,
+// as it would be created on demand.
+func IsDirWritable(dir string) bool {
+	for {
+		if _, err := os.Stat(dir); err == nil {
+			// W_OK | X_OK
+			return syscall.Access(dir, 0x2|0x1) == nil
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return false
+		}
+		dir = parent
+	}
+}
+
 func ResolvePath(name string) (string, error) {
 	if name == "" {
 		return "", errors.New("cannot resolve to empty path")
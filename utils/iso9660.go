@@ -0,0 +1,475 @@
+package utils
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strings"
+)
+
+// Reference: ECMA-119, IEEE P1282 (Rock Ridge) and zisofs
+
+const Iso9660SectorSize = 2048
+const Iso9660PrimaryVolumeDescriptorOffset = 16 * Iso9660SectorSize
+
+var Iso9660StandardIdentifier = []byte("CD001")
+var zisofsMagic = []byte{0x37, 0xE4, 0x53, 0x96, 0xC9, 0xDB, 0xD6, 0x07}
+
+const (
+	iso9660FlagDirectory   = 0x02
+	iso9660FlagMultiExtent = 0x80
+)
+
+type iso9660Record struct {
+	Name        string
+	Extent      uint32
+	Size        uint32
+	IsDir       bool
+	Mode        os.FileMode
+	HasMode     bool
+	SymlinkTo   string
+	IsSymlink   bool
+	IsZisofs    bool
+	IsSelf      bool
+	IsParent    bool
+	SystemUse   []byte
+	MultiExtent bool
+	// Rock Ridge deep directory relocation
+	ChildLink    uint32
+	HasChildLink bool
+	IsRelocated  bool
+}
+
+type iso9660Reader struct {
+	reader   io.ReaderAt
+	suspSkip int
+}
+
+func IsIso9660(reader io.ReaderAt) bool {
+	identifier := make([]byte, len(Iso9660StandardIdentifier))
+	if _, err := reader.ReadAt(identifier, Iso9660PrimaryVolumeDescriptorOffset+1); err != nil {
+		return false
+	}
+	return bytes.Equal(identifier, Iso9660StandardIdentifier)
+}
+
+func ExtractIso9660(reader io.ReaderAt, dest string) error {
+	if !IsIso9660(reader) {
+		return errors.New("missing iso 9660 primary volume descriptor")
+	}
+	pvd := make([]byte, Iso9660SectorSize)
+	if _, err := reader.ReadAt(pvd, Iso9660PrimaryVolumeDescriptorOffset); err != nil {
+		return err
+	}
+	if pvd[0] != 1 {
+		return errors.New("invalid iso 9660 primary volume descriptor")
+	}
+	iso := &iso9660Reader{reader: reader}
+	root, err := iso.parseRecord(pvd[156 : 156+34])
+	if err != nil {
+		return err
+	}
+	// the "." entry of the root directory announces the susp skip length
+	entries, err := iso.readDir(root)
+	if err != nil {
+		return err
+	}
+	for _, x := range entries {
+		if x.IsSelf {
+			iso.suspSkip = suspSkipLength(x.SystemUse)
+			break
+		}
+	}
+	if err := os.MkdirAll(dest, DirPermissions); err != nil {
+		return err
+	}
+	return iso.extractDir(root, dest, 0)
+}
+
+const iso9660MaxDepth = 64
+
+func (iso *iso9660Reader) extractDir(dir *iso9660Record, dest string, depth int) error {
+	if depth > iso9660MaxDepth {
+		return errors.New("iso 9660 directory tree is too deep")
+	}
+	entries, err := iso.readDir(dir)
+	if err != nil {
+		return err
+	}
+	for _, x := range entries {
+		if x.IsSelf || x.IsParent || x.IsRelocated {
+			// relocated directories are extracted at their child link
+			continue
+		}
+		if x.HasChildLink {
+			if err := iso.resolveChildLink(x); err != nil {
+				return err
+			}
+		}
+		if x.Name == "" || x.Name == "." || x.Name == ".." || strings.Contains(x.Name, "/") {
+			return fmt.Errorf("invalid iso 9660 file name %q", x.Name)
+		}
+		target := path.Join(dest, x.Name)
+		if err := checkIso9660Target(target, x.IsDir && !x.IsSymlink); err != nil {
+			return err
+		}
+		switch {
+		case x.IsSymlink:
+			if err := os.Symlink(x.SymlinkTo, target); err != nil {
+				return err
+			}
+
+		case x.IsDir:
+			if err := os.Mkdir(target, x.permissions(0755)|0700); err != nil && !errors.Is(err, os.ErrExist) {
+				return err
+			}
+			if err := iso.extractDir(x, target, depth+1); err != nil {
+				return err
+			}
+
+		default:
+			if err := iso.extractFile(x, target); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Entries with duplicate names must not follow a previously extracted
+// symlink out of the destination.
+func checkIso9660Target(target string, isDir bool) error {
+	info, err := os.Lstat(target)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if info.Mode()&os.ModeSymlink != 0 || !isDir || !info.IsDir() {
+		return fmt.Errorf("duplicate iso 9660 entry %q", path.Base(target))
+	}
+	return nil
+}
+
+// Replaces the placeholder file of a relocated directory with the
+// directory itself, described by the "." entry at the child link.
+func (iso *iso9660Reader) resolveChildLink(record *iso9660Record) error {
+	sector := make([]byte, Iso9660SectorSize)
+	if _, err := iso.reader.ReadAt(sector, int64(record.ChildLink)*Iso9660SectorSize); err != nil {
+		return err
+	}
+	length := int(sector[0])
+	if length == 0 {
+		return fmt.Errorf("invalid iso 9660 child link of %q", record.Name)
+	}
+	self, err := iso.parseRecord(sector[:length])
+	if err != nil {
+		return err
+	}
+	if !self.IsSelf || !self.IsDir {
+		return fmt.Errorf("invalid iso 9660 child link of %q", record.Name)
+	}
+	record.Extent = self.Extent
+	record.Size = self.Size
+	record.IsDir = true
+	record.IsSymlink = false
+	return nil
+}
+
+func (iso *iso9660Reader) extractFile(record *iso9660Record, target string) error {
+	file, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, record.permissions(0644))
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	data := io.NewSectionReader(
+		iso.reader,
+		int64(record.Extent)*Iso9660SectorSize,
+		int64(record.Size),
+	)
+	if record.IsZisofs {
+		return inflateZisofs(data, file)
+	}
+	_, err = io.Copy(file, data)
+	return err
+}
+
+func (record *iso9660Record) permissions(fallback os.FileMode) os.FileMode {
+	if !record.HasMode {
+		return fallback
+	}
+	return record.Mode.Perm()
+}
+
+const iso9660MaxDirSize = 4 * 1024 * 1024
+
+func (iso *iso9660Reader) readDir(dir *iso9660Record) ([]*iso9660Record, error) {
+	if dir.Size > iso9660MaxDirSize {
+		return nil, fmt.Errorf("iso 9660 directory is too large (%d bytes)", dir.Size)
+	}
+	data := make([]byte, dir.Size)
+	if _, err := iso.reader.ReadAt(data, int64(dir.Extent)*Iso9660SectorSize); err != nil {
+		return nil, err
+	}
+	entries := []*iso9660Record{}
+	offset := 0
+	for offset < len(data) {
+		length := int(data[offset])
+		if length == 0 {
+			// records never span sectors, zero length means padding till next sector
+			offset = (offset/Iso9660SectorSize + 1) * Iso9660SectorSize
+			continue
+		}
+		if offset+length > len(data) {
+			return nil, errors.New("iso 9660 directory record overflows its extent")
+		}
+		record, err := iso.parseRecord(data[offset : offset+length])
+		if err != nil {
+			return nil, err
+		}
+		offset += length
+		if n := len(entries); n > 0 && entries[n-1].MultiExtent && entries[n-1].Name == record.Name {
+			return nil, fmt.Errorf("multi-extent iso 9660 file %q is not supported", record.Name)
+		}
+		entries = append(entries, record)
+	}
+	return entries, nil
+}
+
+func (iso *iso9660Reader) parseRecord(data []byte) (*iso9660Record, error) {
+	if len(data) < 34 {
+		return nil, errors.New("iso 9660 directory record is too short")
+	}
+	nameLength := int(data[32])
+	if 33+nameLength > len(data) {
+		return nil, errors.New("iso 9660 file identifier overflows its record")
+	}
+	rawName := data[33 : 33+nameLength]
+	record := &iso9660Record{
+		Extent:      binary.LittleEndian.Uint32(data[2:6]),
+		Size:        binary.LittleEndian.Uint32(data[10:14]),
+		IsDir:       data[25]&iso9660FlagDirectory != 0,
+		MultiExtent: data[25]&iso9660FlagMultiExtent != 0,
+		IsSelf:      nameLength == 1 && rawName[0] == 0,
+		IsParent:    nameLength == 1 && rawName[0] == 1,
+	}
+	record.Name = cleanIso9660Name(string(rawName))
+	systemUseStart := 33 + nameLength
+	if nameLength%2 == 0 {
+		systemUseStart++
+	}
+	if systemUseStart < len(data) {
+		record.SystemUse = data[systemUseStart:]
+	}
+	if err := iso.parseSystemUse(record); err != nil {
+		return nil, err
+	}
+	return record, nil
+}
+
+func cleanIso9660Name(name string) string {
+	if i := strings.LastIndexByte(name, ';'); i != -1 {
+		name = name[:i]
+	}
+	return strings.TrimSuffix(name, ".")
+}
+
+func suspSkipLength(systemUse []byte) int {
+	if len(systemUse) >= 7 && string(systemUse[0:2]) == "SP" {
+		return int(systemUse[6])
+	}
+	return 0
+}
+
+const iso9660MaxContinuations = 32
+
+func (iso *iso9660Reader) parseSystemUse(record *iso9660Record) error {
+	data := record.SystemUse
+	if len(data) < iso.suspSkip {
+		return nil
+	}
+	data = data[iso.suspSkip:]
+	alternateName := ""
+	hasAlternateName := false
+	symlink := []string{}
+	symlinkComponent := ""
+	continuations := 0
+	for {
+		var continuation []byte
+		for len(data) >= 4 {
+			signature := string(data[0:2])
+			length := int(data[2])
+			if length < 4 || length > len(data) {
+				break
+			}
+			entry := data[:length]
+			data = data[length:]
+			switch signature {
+			case "ST":
+				data = nil
+
+			case "CE":
+				if length < 28 {
+					continue
+				}
+				continuations++
+				if continuations > iso9660MaxContinuations {
+					return errors.New("too many iso 9660 continuation areas")
+				}
+				continuation = entry
+
+			case "NM":
+				if length < 5 {
+					continue
+				}
+				flags := entry[4]
+				if flags&0x06 != 0 {
+					continue
+				}
+				alternateName += string(entry[5:])
+				hasAlternateName = true
+
+			case "PX":
+				if length < 8 {
+					continue
+				}
+				mode := binary.LittleEndian.Uint32(entry[4:8])
+				record.Mode = os.FileMode(mode & 0777)
+				record.HasMode = true
+				if mode&0170000 == 0120000 {
+					record.IsSymlink = true
+				}
+
+			case "SL":
+				if length < 5 {
+					continue
+				}
+				record.IsSymlink = true
+				components := entry[5:]
+				for len(components) >= 2 {
+					flags := components[0]
+					size := int(components[1])
+					if 2+size > len(components) {
+						break
+					}
+					content := string(components[2 : 2+size])
+					components = components[2+size:]
+					switch {
+					case flags&0x02 != 0:
+						symlinkComponent += "."
+					case flags&0x04 != 0:
+						symlinkComponent += ".."
+					case flags&0x08 != 0:
+						symlinkComponent = ""
+						symlink = []string{""}
+					default:
+						symlinkComponent += content
+					}
+					if flags&0x01 == 0 && flags&0x08 == 0 {
+						symlink = append(symlink, symlinkComponent)
+						symlinkComponent = ""
+					}
+				}
+
+			case "CL":
+				if length < 12 {
+					continue
+				}
+				record.ChildLink = binary.LittleEndian.Uint32(entry[4:8])
+				record.HasChildLink = true
+
+			case "RE":
+				record.IsRelocated = true
+
+			case "ZF":
+				if length < 16 || string(entry[4:6]) != "pz" {
+					continue
+				}
+				record.IsZisofs = true
+			}
+		}
+		if continuation == nil {
+			break
+		}
+		// entries of the current area are read before following the continuation
+		block := binary.LittleEndian.Uint32(continuation[4:8])
+		offset := binary.LittleEndian.Uint32(continuation[12:16])
+		size := binary.LittleEndian.Uint32(continuation[20:24])
+		data = make([]byte, size)
+		at := int64(block)*Iso9660SectorSize + int64(offset)
+		if _, err := iso.reader.ReadAt(data, at); err != nil {
+			return err
+		}
+	}
+	if hasAlternateName {
+		record.Name = alternateName
+	}
+	if record.IsSymlink {
+		record.SymlinkTo = strings.Join(symlink, "/")
+		if record.SymlinkTo == "" && len(symlink) == 1 {
+			record.SymlinkTo = "/"
+		}
+	}
+	return nil
+}
+
+func inflateZisofs(reader *io.SectionReader, writer io.Writer) error {
+	header := make([]byte, 16)
+	n, err := reader.ReadAt(header, 0)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return err
+	}
+	if n < len(header) || !bytes.Equal(header[0:8], zisofsMagic) {
+		// files smaller than a block are sometimes stored uncompressed
+		_, err := io.Copy(writer, io.NewSectionReader(reader, 0, reader.Size()))
+		return err
+	}
+	size := int64(binary.LittleEndian.Uint32(header[8:12]))
+	headerSize := int64(header[12]) * 4
+	blockSizeLog2 := header[13]
+	if blockSizeLog2 < 15 || blockSizeLog2 > 17 {
+		return fmt.Errorf("invalid zisofs block size 2^%d", blockSizeLog2)
+	}
+	blockSize := int64(1) << blockSizeLog2
+	blocksCount := (size + blockSize - 1) / blockSize
+	pointers := make([]byte, (blocksCount+1)*4)
+	if _, err := reader.ReadAt(pointers, headerSize); err != nil {
+		return err
+	}
+	remaining := size
+	for i := int64(0); i < blocksCount; i++ {
+		start := int64(binary.LittleEndian.Uint32(pointers[i*4:]))
+		end := int64(binary.LittleEndian.Uint32(pointers[(i+1)*4:]))
+		expected := min(blockSize, remaining)
+		if end < start {
+			return errors.New("invalid zisofs block pointers")
+		}
+		if end == start {
+			if _, err := writer.Write(make([]byte, expected)); err != nil {
+				return err
+			}
+			remaining -= expected
+			continue
+		}
+		inflater, err := zlib.NewReader(io.NewSectionReader(reader, start, end-start))
+		if err != nil {
+			return err
+		}
+		written, err := io.Copy(writer, io.LimitReader(inflater, expected))
+		inflater.Close()
+		if err != nil {
+			return err
+		}
+		if written != expected {
+			return errors.New("truncated zisofs block")
+		}
+		remaining -= expected
+	}
+	return nil
+}
@@ -0,0 +1,437 @@
+package utils
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"os"
+	"path"
+	"testing"
+)
+
+// Builds ISO 9660 images in memory, directories have to fit into a sector.
+type isoTestImage struct {
+	data []byte
+}
+
+type isoTestEntry struct {
+	name      string
+	flags     byte
+	extent    uint32
+	size      uint32
+	systemUse []byte
+}
+
+func newIsoTestImage() *isoTestImage {
+	return &isoTestImage{data: make([]byte, 18*Iso9660SectorSize)}
+}
+
+func (img *isoTestImage) nextSector() uint32 {
+	return uint32(len(img.data) / Iso9660SectorSize)
+}
+
+func (img *isoTestImage) addFile(content []byte) isoTestEntry {
+	extent := img.nextSector()
+	img.data = append(img.data, content...)
+	img.pad()
+	return isoTestEntry{extent: extent, size: uint32(len(content))}
+}
+
+func (img *isoTestImage) addDir(selfSystemUse []byte, entries ...isoTestEntry) isoTestEntry {
+	extent := img.nextSector()
+	dir := isoTestEntry{
+		name:      "\x00",
+		flags:     iso9660FlagDirectory,
+		extent:    extent,
+		size:      Iso9660SectorSize,
+		systemUse: selfSystemUse,
+	}
+	data := isoTestRecord(dir)
+	data = append(data, isoTestRecord(isoTestEntry{name: "\x01", flags: iso9660FlagDirectory})...)
+	for _, x := range entries {
+		data = append(data, isoTestRecord(x)...)
+	}
+	if len(data) > Iso9660SectorSize {
+		panic("directory does not fit into a sector")
+	}
+	img.data = append(img.data, data...)
+	img.pad()
+	return dir
+}
+
+func (img *isoTestImage) pad() {
+	if rest := len(img.data) % Iso9660SectorSize; rest != 0 {
+		img.data = append(img.data, make([]byte, Iso9660SectorSize-rest)...)
+	}
+}
+
+func (img *isoTestImage) finish(root isoTestEntry) []byte {
+	pvd := img.data[Iso9660PrimaryVolumeDescriptorOffset:]
+	pvd[0] = 1
+	copy(pvd[1:6], Iso9660StandardIdentifier)
+	pvd[6] = 1
+	copy(pvd[156:190], isoTestRecord(root))
+	terminator := pvd[Iso9660SectorSize:]
+	terminator[0] = 255
+	copy(terminator[1:6], Iso9660StandardIdentifier)
+	terminator[6] = 1
+	return img.data
+}
+
+func isoTestRecord(entry isoTestEntry) []byte {
+	length := 33 + len(entry.name)
+	if len(entry.name)%2 == 0 {
+		length++
+	}
+	record := make([]byte, length)
+	binary.LittleEndian.PutUint32(record[2:6], entry.extent)
+	binary.BigEndian.PutUint32(record[6:10], entry.extent)
+	binary.LittleEndian.PutUint32(record[10:14], entry.size)
+	binary.BigEndian.PutUint32(record[14:18], entry.size)
+	record[25] = entry.flags
+	binary.LittleEndian.PutUint16(record[28:30], 1)
+	record[32] = byte(len(entry.name))
+	copy(record[33:], entry.name)
+	record = append(record, entry.systemUse...)
+	if len(record)%2 != 0 {
+		record = append(record, 0)
+	}
+	record[0] = byte(len(record))
+	return record
+}
+
+func isoTestSusp(signature string, data ...byte) []byte {
+	entry := []byte{signature[0], signature[1], byte(4 + len(data)), 1}
+	return append(entry, data...)
+}
+
+func isoTestBothEndian(value uint32) []byte {
+	data := make([]byte, 8)
+	binary.LittleEndian.PutUint32(data[0:4], value)
+	binary.BigEndian.PutUint32(data[4:8], value)
+	return data
+}
+
+func isoTestSp() []byte {
+	return isoTestSusp("SP", 0xBE, 0xEF, 0)
+}
+
+func isoTestNm(name string) []byte {
+	return isoTestSusp("NM", append([]byte{0}, name...)...)
+}
+
+func isoTestPx(mode uint32) []byte {
+	data := isoTestBothEndian(mode)
+	data = append(data, isoTestBothEndian(1)...)
+	data = append(data, isoTestBothEndian(0)...)
+	data = append(data, isoTestBothEndian(0)...)
+	return isoTestSusp("PX", data...)
+}
+
+// Components are given as flags followed by their content.
+func isoTestSl(components ...any) []byte {
+	data := []byte{0}
+	for i := 0; i < len(components); i += 2 {
+		content := components[i+1].(string)
+		data = append(data, components[i].(byte), byte(len(content)))
+		data = append(data, content...)
+	}
+	return isoTestSusp("SL", data...)
+}
+
+func isoTestCe(block uint32, offset uint32, size uint32) []byte {
+	data := isoTestBothEndian(block)
+	data = append(data, isoTestBothEndian(offset)...)
+	data = append(data, isoTestBothEndian(size)...)
+	return isoTestSusp("CE", data...)
+}
+
+func isoTestZf(size uint32) []byte {
+	data := []byte{'p', 'z', 4, 15}
+	data = append(data, isoTestBothEndian(size)...)
+	return isoTestSusp("ZF", data...)
+}
+
+func isoTestConcat(parts ...[]byte) []byte {
+	return bytes.Join(parts, nil)
+}
+
+// Compresses the content into zisofs blocks of 32 KiB, blocks that only
+// contain zeros are stored as empty blocks.
+func isoTestZisofs(content []byte) []byte {
+	const blockSize = 1 << 15
+	blocksCount := (len(content) + blockSize - 1) / blockSize
+	header := make([]byte, 16)
+	copy(header, zisofsMagic)
+	binary.LittleEndian.PutUint32(header[8:12], uint32(len(content)))
+	header[12] = 4
+	header[13] = 15
+	pointers := make([]byte, (blocksCount+1)*4)
+	blocks := []byte{}
+	offset := len(header) + len(pointers)
+	for i := 0; i < blocksCount; i++ {
+		binary.LittleEndian.PutUint32(pointers[i*4:], uint32(offset+len(blocks)))
+		block := content[i*blockSize : min((i+1)*blockSize, len(content))]
+		if bytes.Count(block, []byte{0}) == len(block) {
+			continue
+		}
+		var compressed bytes.Buffer
+		writer := zlib.NewWriter(&compressed)
+		writer.Write(block)
+		writer.Close()
+		blocks = append(blocks, compressed.Bytes()...)
+	}
+	binary.LittleEndian.PutUint32(pointers[blocksCount*4:], uint32(offset+len(blocks)))
+	return isoTestConcat(header, pointers, blocks)
+}
+
+func isoTestNamed(entry isoTestEntry, name string, flags byte, systemUse ...[]byte) isoTestEntry {
+	entry.name = name
+	entry.flags = flags
+	entry.systemUse = isoTestConcat(systemUse...)
+	return entry
+}
+
+func TestIsIso9660(t *testing.T) {
+	img := newIsoTestImage()
+	root := img.addDir(nil)
+	if !IsIso9660(bytes.NewReader(img.finish(root))) {
+		t.Error("expected an iso 9660 image")
+	}
+	if IsIso9660(bytes.NewReader(make([]byte, 64))) {
+		t.Error("expected no iso 9660 image")
+	}
+}
+
+type isoTestExpectation struct {
+	name    string
+	content string
+	link    string
+	mode    os.FileMode
+	isDir   bool
+	missing bool
+}
+
+func TestExtractIso9660(t *testing.T) {
+	tests := []struct {
+		name   string
+		build  func(img *isoTestImage) isoTestEntry
+		expect []isoTestExpectation
+	}{
+		{
+			name: "plain iso 9660 names",
+			build: func(img *isoTestImage) isoTestEntry {
+				bar := img.addFile([]byte("bar"))
+				dir := img.addDir(nil, isoTestNamed(bar, "BAR.;1", 0))
+				foo := img.addFile([]byte("foo"))
+				return img.addDir(nil, isoTestNamed(foo, "FOO.TXT;1", 0), isoTestNamed(dir, "DIR", iso9660FlagDirectory))
+			},
+			expect: []isoTestExpectation{
+				{name: "FOO.TXT", content: "foo", mode: 0644},
+				{name: "DIR", isDir: true},
+				{name: "DIR/BAR", content: "bar", mode: 0644},
+			},
+		},
+		{
+			name: "rock ridge names, modes and symlinks",
+			build: func(img *isoTestImage) isoTestEntry {
+				app := img.addFile([]byte("#!/bin/sh\n"))
+				lib := img.addDir(nil, isoTestNamed(app, "APP;1", 0, isoTestNm("app-bin"), isoTestPx(0100755)))
+				link := isoTestEntry{}
+				bin := img.addDir(nil,
+					isoTestNamed(link, "APP;1", 0, isoTestNm("app"), isoTestPx(0120777), isoTestSl(byte(0x04), "", byte(0), "lib", byte(0), "app-bin")),
+					isoTestNamed(link, "ABS;1", 0, isoTestNm("abs"), isoTestSl(byte(0x08), "", byte(0), "usr", byte(0), "bin")),
+					isoTestNamed(link, "CUR;1", 0, isoTestNm("cur"), isoTestSl(byte(0x02), "")),
+				)
+				return img.addDir(
+					isoTestSp(),
+					isoTestNamed(lib, "LIB", iso9660FlagDirectory, isoTestNm("lib"), isoTestPx(040700)),
+					isoTestNamed(bin, "BIN", iso9660FlagDirectory, isoTestNm("bin")),
+				)
+			},
+			expect: []isoTestExpectation{
+				{name: "lib", isDir: true, mode: 0700},
+				{name: "lib/app-bin", content: "#!/bin/sh\n", mode: 0755},
+				{name: "bin/app", link: "../lib/app-bin"},
+				{name: "bin/abs", link: "/usr/bin"},
+				{name: "bin/cur", link: "."},
+				{name: "LIB", missing: true},
+			},
+		},
+		{
+			name: "continuation areas",
+			build: func(img *isoTestImage) isoTestEntry {
+				area := img.addFile(isoTestConcat(isoTestNm("name"), isoTestPx(0100600)))
+				foo := img.addFile([]byte("foo"))
+				return img.addDir(nil, isoTestNamed(foo, "LONG;1", 0, isoTestNm("long"), isoTestCe(area.extent, 0, area.size)))
+			},
+			expect: []isoTestExpectation{
+				{name: "longname", content: "foo", mode: 0600},
+			},
+		},
+		{
+			name: "relocated directories",
+			build: func(img *isoTestImage) isoTestEntry {
+				file := img.addFile([]byte("deep"))
+				deep := img.addDir(nil, isoTestNamed(file, "FILE;1", 0, isoTestNm("file")))
+				moved := img.addDir(nil, isoTestNamed(deep, "DEEP", iso9660FlagDirectory, isoTestNm("deep"), isoTestSusp("RE")))
+				child := isoTestSusp("CL", isoTestBothEndian(deep.extent)...)
+				placeholder := img.addFile(nil)
+				a := img.addDir(nil, isoTestNamed(placeholder, "DEEP;1", 0, isoTestNm("deep"), isoTestPx(040755), child))
+				return img.addDir(
+					isoTestSp(),
+					isoTestNamed(a, "A", iso9660FlagDirectory, isoTestNm("a")),
+					isoTestNamed(moved, "RR_MOVED", iso9660FlagDirectory, isoTestNm("rr_moved")),
+				)
+			},
+			expect: []isoTestExpectation{
+				{name: "a/deep", isDir: true, mode: 0755},
+				{name: "a/deep/file", content: "deep", mode: 0644},
+				{name: "rr_moved/deep", missing: true},
+			},
+		},
+		{
+			name: "zisofs",
+			build: func(img *isoTestImage) isoTestEntry {
+				content := bytes.Repeat([]byte("zisofs "), 5000)
+				content = append(content, make([]byte, 1<<15)...)
+				content = append(content, "tail"...)
+				compressed := img.addFile(isoTestZisofs(content))
+				small := img.addFile([]byte("stored"))
+				empty := img.addFile(isoTestZisofs(nil))
+				return img.addDir(nil,
+					isoTestNamed(compressed, "BIG;1", 0, isoTestNm("big"), isoTestZf(uint32(len(content)))),
+					isoTestNamed(small, "SMALL;1", 0, isoTestNm("small"), isoTestZf(6)),
+					isoTestNamed(empty, "EMPTY;1", 0, isoTestNm("empty"), isoTestZf(0)),
+				)
+			},
+			expect: []isoTestExpectation{
+				{name: "big", content: string(bytes.Repeat([]byte("zisofs "), 5000)) + string(make([]byte, 1<<15)) + "tail"},
+				{name: "small", content: "stored"},
+				{name: "empty", content: ""},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			img := newIsoTestImage()
+			data := img.finish(tt.build(img))
+			dest := t.TempDir()
+			if err := ExtractIso9660(bytes.NewReader(data), dest); err != nil {
+				t.Fatal(err)
+			}
+			for _, x := range tt.expect {
+				name := path.Join(dest, x.name)
+				info, err := os.Lstat(name)
+				if x.missing {
+					if err == nil {
+						t.Errorf("%s: expected to be missing", x.name)
+					}
+					continue
+				}
+				if err != nil {
+					t.Errorf("%s: %v", x.name, err)
+					continue
+				}
+				switch {
+				case x.link != "":
+					link, err := os.Readlink(name)
+					if err != nil || link != x.link {
+						t.Errorf("%s: got link %q, want %q", x.name, link, x.link)
+					}
+				case x.isDir:
+					if !info.IsDir() {
+						t.Errorf("%s: expected a directory", x.name)
+					}
+				default:
+					content, err := os.ReadFile(name)
+					if err != nil || string(content) != x.content {
+						t.Errorf("%s: got %d bytes, want %d bytes", x.name, len(content), len(x.content))
+					}
+				}
+				if x.mode != 0 && info.Mode().Perm() != x.mode {
+					t.Errorf("%s: got mode %v, want %v", x.name, info.Mode().Perm(), x.mode)
+				}
+			}
+		})
+	}
+}
+
+func TestExtractIso9660Errors(t *testing.T) {
+	tests := []struct {
+		name  string
+		build func(img *isoTestImage) isoTestEntry
+	}{
+		{
+			name: "path separator in name",
+			build: func(img *isoTestImage) isoTestEntry {
+				foo := img.addFile([]byte("foo"))
+				return img.addDir(nil, isoTestNamed(foo, "FOO;1", 0, isoTestNm("../foo")))
+			},
+		},
+		{
+			name: "parent directory name",
+			build: func(img *isoTestImage) isoTestEntry {
+				foo := img.addFile([]byte("foo"))
+				return img.addDir(nil, isoTestNamed(foo, "FOO;1", 0, isoTestNm("..")))
+			},
+		},
+		{
+			name: "duplicate entry after a symlink",
+			build: func(img *isoTestImage) isoTestEntry {
+				foo := img.addFile([]byte("foo"))
+				return img.addDir(nil,
+					isoTestNamed(isoTestEntry{}, "LINK;1", 0, isoTestNm("link"), isoTestSl(byte(0x08), "", byte(0), "tmp")),
+					isoTestNamed(foo, "LINK;2", 0, isoTestNm("link")),
+				)
+			},
+		},
+		{
+			name: "multi-extent file",
+			build: func(img *isoTestImage) isoTestEntry {
+				foo := img.addFile([]byte("foo"))
+				return img.addDir(nil,
+					isoTestNamed(foo, "FOO;1", iso9660FlagMultiExtent),
+					isoTestNamed(foo, "FOO;1", 0),
+				)
+			},
+		},
+		{
+			name: "invalid zisofs block size",
+			build: func(img *isoTestImage) isoTestEntry {
+				content := isoTestZisofs([]byte("foo"))
+				content[13] = 14
+				foo := img.addFile(content)
+				return img.addDir(nil, isoTestNamed(foo, "FOO;1", 0, isoTestZf(3)))
+			},
+		},
+		{
+			name: "child link to a file",
+			build: func(img *isoTestImage) isoTestEntry {
+				foo := img.addFile([]byte("foo"))
+				return img.addDir(nil, isoTestNamed(foo, "DEEP;1", 0, isoTestSusp("CL", isoTestBothEndian(foo.extent)...)))
+			},
+		},
+		{
+			name: "continuation loop",
+			build: func(img *isoTestImage) isoTestEntry {
+				extent := img.nextSector()
+				area := img.addFile(isoTestCe(extent, 0, 28))
+				foo := img.addFile([]byte("foo"))
+				return img.addDir(nil, isoTestNamed(foo, "FOO;1", 0, isoTestCe(area.extent, 0, area.size)))
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			img := newIsoTestImage()
+			data := img.finish(tt.build(img))
+			if err := ExtractIso9660(bytes.NewReader(data), t.TempDir()); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+	if err := ExtractIso9660(bytes.NewReader(make([]byte, 64)), t.TempDir()); err == nil {
+		t.Error("missing primary volume descriptor: expected an error")
+	}
+}
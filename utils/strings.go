@@ -1,7 +1,9 @@
 package utils
 
 import (
+	"fmt"
 	"regexp"
+	"sort"
 	"strings"
 )
 
@@ -31,6 +33,20 @@ func EncloseWhen(text string, when string, start string, end string) string {
 	return start + text + end
 }
 
+// Formats as KEY=VALUE pairs sorted by key.
+func FormatEnvMap(env map[string]string) []string {
+	keys := make([]string, 0, len(env))
+	for k := range env {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	pairs := []string{}
+	for _, k := range keys {
+		pairs = append(pairs, fmt.Sprintf("%s=%s", k, env[k]))
+	}
+	return pairs
+}
+
 func BoolToYesNo(value bool) string {
 	if value {
 		return "yes"
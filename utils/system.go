@@ -1,8 +1,11 @@
 package utils
 
 import (
+	"fmt"
 	"os"
 	"os/exec"
+	"path/filepath"
+	"runtime"
 	"strings"
 	"syscall"
 )
@@ -10,35 +13,70 @@ import (
 var ArchMap = map[string][]string{
 	"amd64": {"amd64", "x86_64", "x86-64"},
 	"386":   {"i386", "i686"},
-	"arm64": {"armhf", "aarch64"},
-	"arm":   {"arm"},
+	"arm64": {"arm64", "aarch64"},
+	"arm":   {"armhf", "armv7l", "armv7", "arm"},
 }
 
-func GetSystemArch() string {
-	raw, _ := ExecUnameM()
+// pho is built for the userland it runs on, which is more reliable than the
+// machine name of the kernel (such as 32-bit userlands on 64-bit kernels).
+func GetSystemArch() (string, error) {
+	if _, ok := ArchMap[runtime.GOARCH]; ok {
+		return runtime.GOARCH, nil
+	}
+	machine, err := GetMachineName()
+	if err != nil {
+		return "", err
+	}
+	if arch := FindArch(machine); arch != "" {
+		return arch, nil
+	}
+	return "", fmt.Errorf("unsupported system architecture %s", machine)
+}
+
+func FindArch(name string) string {
+	name = strings.ToLower(name)
 	for arch, aliases := range ArchMap {
-		for _, alias := range aliases {
-			if alias == raw {
-				return arch
-			}
+		if name == arch || SliceContains(aliases, name) {
+			return arch
 		}
 	}
 	return ""
 }
 
-func ExecUnameM() (string, error) {
-	cmd := exec.Command("uname", "-m")
-	output, err := cmd.Output()
-	if err != nil {
+func HasExecutable(name string) bool {
+	_, err := exec.LookPath(name)
+	return err == nil
+}
+
+func IsDirInPath(dir string) bool {
+	for _, x := range filepath.SplitList(os.Getenv("PATH")) {
+		if x != "" && filepath.Clean(x) == filepath.Clean(dir) {
+			return true
+		}
+	}
+	return false
+}
+
+func GetMachineName() (string, error) {
+	var uname syscall.Utsname
+	if err := syscall.Uname(&uname); err != nil {
 		return "", err
 	}
-	return strings.TrimSpace(string(output)), nil
+	name := []byte{}
+	for _, x := range uname.Machine {
+		if x == 0 {
+			break
+		}
+		name = append(name, byte(x))
+	}
+	return string(name), nil
 }
 
 type StartDetachedProcessOptions struct {
 	Dir  string
 	Exec string
 	Args []string
+	Env  []string
 }
 
 func StartDetachedProcess(options *StartDetachedProcessOptions) error {
@@ -56,7 +94,7 @@ func StartDetachedProcess(options *StartDetachedProcessOptions) error {
 	}
 	procAttr := &os.ProcAttr{
 		Dir: options.Dir,
-		Env: os.Environ(),
+		Env: append(os.Environ(), options.Env...),
 		Files: []*os.File{
 			stdin,
 			stdout,
@@ -0,0 +1,45 @@
+package utils
+
+import (
+	"os"
+	"path"
+	"strings"
+)
+
+// Reference: https://specifications.freedesktop.org/basedir-spec/latest/
+
+func GetXdgDataHome() (string, error) {
+	return getXdgDir("XDG_DATA_HOME", ".local/share")
+}
+
+func GetXdgConfigHome() (string, error) {
+	return getXdgDir("XDG_CONFIG_HOME", ".config")
+}
+
+func GetXdgStateHome() (string, error) {
+	return getXdgDir("XDG_STATE_HOME", ".local/state")
+}
+
+func GetXdgDataDirs() []string {
+	dirs := []string{}
+	for _, x := range strings.Split(os.Getenv("XDG_DATA_DIRS"), ":") {
+		if path.IsAbs(x) {
+			dirs = append(dirs, x)
+		}
+	}
+	if len(dirs) == 0 {
+		dirs = []string{"/usr/local/share", "/usr/share"}
+	}
+	return dirs
+}
+
+func getXdgDir(env string, fallback string) (string, error) {
+	if dir := os.Getenv(env); dir != "" && path.IsAbs(dir) {
+		return dir, nil
+	}
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return path.Join(homeDir, fallback), nil
+}